@@ -2,55 +2,104 @@ package external
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"headlessBrowser-worker/domain/model"
+	"log/slog"
 	"time"
 
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 )
 
-type ChromeAdapter struct{}
+// navigationTimeout bounds loading the document; the readiness wait has its
+// own timeout on top
+const navigationTimeout = 30 * time.Second
 
-func (c *ChromeAdapter) GetRenderedHTML(targetURL string) (string, error) {
-	// 1. Setup options (Headless mode is default)
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.NoSandbox,                           // Crucial for Docker
-		chromedp.DisableGPU,                          // Usually necessary in containers
-		chromedp.Flag("disable-dev-shm-usage", true), // Prevents crashes in small containers
-		chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-	)
-
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer cancel()
+type ChromeAdapter struct {
+	Pool *BrowserPool
+	// MaxBytes limits the size of the captured document; 0 means no limit
+	MaxBytes int64
+}
 
-	ctx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
-	defer cancel()
+// GetRenderedHTML renders targetURL in a fresh incognito tab of a pooled
+// browser and captures it once the wait condition of opts is met;
+// cancelling ctx closes the tab
+func (c *ChromeAdapter) GetRenderedHTML(ctx context.Context, targetURL string, opts model.RenderOptions) (rendered *model.RenderedPage, err error) {
+	tabCtx, release, err := c.Pool.NewTab(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { release(err) }()
 
-	// 2. Set a generous timeout for Elakiri's heavy load
-	ctx, cancel = context.WithTimeout(ctx, 45*time.Second)
+	wait := opts.WaitOrDefault()
+	tabCtx, cancel := context.WithTimeout(tabCtx, navigationTimeout+wait.Timeout())
 	defer cancel()
 
-	var htmlContent string
-
-	err := chromedp.Run(ctx,
-		chromedp.EmulateViewport(1920, 5000),
-		chromedp.Navigate(targetURL),
-
-		// 1. Wait for the 'body' instead of 'footer' (every site has a body)
-		chromedp.WaitVisible(`body`, chromedp.ByQuery),
-
-		// 2. Optional: Scroll to trigger any lazy-loading
-		chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil),
-
-		// 3. Instead of waiting for a footer, just sleep briefly
-		// to let dynamic JS finish loading.
-		chromedp.Sleep(5*time.Second),
-
-		chromedp.OuterHTML(`html`, &htmlContent),
+	events := listenPageEvents(tabCtx)
+	recorder := recordNetwork(tabCtx)
+	console := recordConsole(tabCtx)
+	redirects := recordRedirects(tabCtx)
+	err = chromedp.Run(tabCtx,
+		emulate(opts.EmulationOrDefault()),
+		installVitalsObservers(),
+		navigate(targetURL),
 	)
+	if err != nil {
+		return nil, err
+	}
 
+	readiness, err := waitReady(tabCtx, wait, events)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	rendered = &model.RenderedPage{Mode: model.RenderBrowser, Readiness: readiness}
+	// Measured before scrolling, which could count as layout shifts. Vitals
+	// are optional, so a page that breaks the script only loses them.
+	if vitalsErr := chromedp.Run(tabCtx, collectVitals(&rendered.Vitals)); vitalsErr != nil {
+		slog.Warn("failed to collect web vitals", "url", targetURL, "error", vitalsErr)
+		rendered.Vitals = model.VitalsSample{}
+	}
+	// Scroll to trigger any lazy-loading and let it finish; a network idle
+	// wait that succeeded already scrolled before going idle
+	if wait.Strategy != model.WaitNetworkIdle || readiness.TimedOut {
+		if err = scrollAndSettle(tabCtx, events); err != nil {
+			return nil, err
+		}
+	}
+	var actions chromedp.Tasks
+	if opts.Screenshot != nil {
+		rendered.Screenshot = &model.Screenshot{}
+		actions = append(actions, captureScreenshot(*opts.Screenshot, rendered.Screenshot))
 	}
+	if opts.PDF != nil {
+		actions = append(actions, printPDF(*opts.PDF, &rendered.PDF))
+	}
+	actions = append(actions, chromedp.OuterHTML(`html`, &rendered.HTML))
+	if err = chromedp.Run(tabCtx, actions); err != nil {
+		return nil, err
+	}
+	if c.MaxBytes > 0 && int64(len(rendered.HTML)) > c.MaxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrDocumentTooLarge, c.MaxBytes)
+	}
+	rendered.Network, rendered.PageTimings = recorder.snapshot()
+	rendered.Console = console.snapshot()
+	rendered.Redirects, rendered.FinalURL, rendered.Status = redirects.snapshot()
+
+	return rendered, nil
+}
 
-	return htmlContent, nil
+// navigate starts loading targetURL without waiting for the load event, so
+// the readiness strategy decides how long to wait
+func navigate(targetURL string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, errorText, _, err := page.Navigate(targetURL).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if errorText != "" {
+			return fmt.Errorf("page load error %s", errorText)
+		}
+		return nil
+	})
 }
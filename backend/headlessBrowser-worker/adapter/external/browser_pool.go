@@ -0,0 +1,248 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+var errPoolClosed = errors.New("browser pool is closed")
+
+// launchTimeout bounds the start of a Chrome process
+const launchTimeout = 30 * time.Second
+
+// browser is one long-lived Chrome process shared by several jobs
+type browser struct {
+	id     int
+	ctx    context.Context
+	cancel context.CancelFunc
+	// active is the number of open tabs, pages the number served so far
+	active   int
+	pages    int
+	retiring bool
+}
+
+// BrowserPool keeps a few Chrome processes running and hands out isolated
+// incognito tabs, so a job no longer pays for launching Chrome. A browser is
+// replaced after MaxPages pages or as soon as it stops responding.
+type BrowserPool struct {
+	size     int
+	maxPages int
+	opts     []chromedp.ExecAllocatorOption
+	// start launches a browser and probe checks that it still responds;
+	// tests replace them to run without Chrome
+	start func(ctx context.Context) (context.Context, context.CancelFunc, error)
+	probe func(b *browser) bool
+
+	mu       sync.Mutex
+	browsers []*browser
+	// launching counts browsers being started outside of mu
+	launching int
+	// changed is closed and replaced whenever a launch ends, waking
+	// acquires that found no browser to use
+	changed chan struct{}
+	nextID  int
+	closed  bool
+}
+
+func NewBrowserPool(size, maxPages int) *BrowserPool {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.NoSandbox,                           // Crucial for Docker
+		chromedp.DisableGPU,                          // Usually necessary in containers
+		chromedp.Flag("disable-dev-shm-usage", true), // Prevents crashes in small containers
+		chromedp.UserAgent(defaultUserAgent),
+	)
+	p := &BrowserPool{size: size, maxPages: maxPages, opts: opts, changed: make(chan struct{})}
+	p.start, p.probe = p.startChrome, p.alive
+	return p
+}
+
+// NewTab opens an incognito tab that is closed when ctx is done or release
+// is called. release takes the error of the work done in the tab so a
+// crashed browser can be recycled.
+func (p *BrowserPool) NewTab(ctx context.Context) (context.Context, func(err error), error) {
+	b, err := p.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tabCtx, cancelTab := chromedp.NewContext(b.ctx, chromedp.WithNewBrowserContext(), chromedp.WithLogf(log.Printf))
+	// The tab lives under the browser, so follow the job's cancellation by hand
+	stop := context.AfterFunc(ctx, cancelTab)
+
+	release := func(err error) {
+		stop()
+		cancelTab()
+		p.release(b, err)
+	}
+	return tabCtx, release, nil
+}
+
+// Close shuts down every browser
+func (p *BrowserPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	close(p.changed)
+	p.changed = make(chan struct{})
+	for _, b := range p.browsers {
+		b.cancel()
+	}
+	p.browsers = nil
+}
+
+// acquire picks the least busy browser, launching one while the pool is
+// below its size. Chrome starts outside of p.mu, so a slow launch holds up
+// neither other jobs nor releases; when every slot is busy launching,
+// acquire waits for one of them or for ctx.
+func (p *BrowserPool) acquire(ctx context.Context) (*browser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if p.closed {
+			return nil, errPoolClosed
+		}
+		var best *browser
+		for _, b := range p.browsers {
+			if !b.retiring && (best == nil || b.active < best.active) {
+				best = b
+			}
+		}
+		room := p.liveCount()+p.launching < p.size
+		if best != nil && (best.active == 0 || !room) {
+			best.active++
+			return best, nil
+		}
+		if room {
+			return p.launch(ctx)
+		}
+
+		changed := p.changed
+		p.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+		}
+		p.mu.Lock()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// launch starts a browser for acquire and takes a tab of it; callers hold
+// p.mu, which is released while Chrome starts
+func (p *BrowserPool) launch(ctx context.Context) (*browser, error) {
+	p.launching++
+	p.mu.Unlock()
+	browserCtx, cancel, err := p.start(ctx)
+	p.mu.Lock()
+	p.launching--
+	close(p.changed)
+	p.changed = make(chan struct{})
+
+	if err != nil {
+		return nil, err
+	}
+	if p.closed {
+		cancel()
+		return nil, errPoolClosed
+	}
+	p.nextID++
+	b := &browser{id: p.nextID, ctx: browserCtx, cancel: cancel, active: 1}
+	p.browsers = append(p.browsers, b)
+	slog.Info("browser launched", "browser", b.id, "pool_size", len(p.browsers))
+	return b, nil
+}
+
+// release returns a tab of b. If the work in it failed and b no longer
+// responds, b is recycled.
+func (p *BrowserPool) release(b *browser, err error) {
+	crashed := err != nil && !p.probe(b)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b.active--
+	b.pages++
+	if crashed {
+		slog.Warn("browser stopped responding, recycling", "browser", b.id)
+		b.retiring = true
+	} else if b.pages >= p.maxPages && !b.retiring {
+		slog.Info("browser reached page limit, recycling", "browser", b.id, "pages", b.pages)
+		b.retiring = true
+	}
+	// Let in-flight tabs finish before the process goes away
+	if b.retiring && b.active == 0 {
+		b.cancel()
+		p.remove(b)
+	}
+}
+
+// startChrome launches a Chrome process that outlives ctx. The launch is
+// abandoned after launchTimeout or once ctx is done.
+func (p *BrowserPool) startChrome(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), p.opts...)
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	cancel := func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+
+	started := make(chan error, 1)
+	// Running with no actions starts the browser
+	go func() { started <- chromedp.Run(browserCtx) }()
+	timer := time.NewTimer(launchTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-started:
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		return browserCtx, cancel, nil
+	case <-timer.C:
+		cancel()
+		return nil, nil, fmt.Errorf("browser did not start within %v", launchTimeout)
+	case <-ctx.Done():
+		cancel()
+		return nil, nil, ctx.Err()
+	}
+}
+
+// alive probes the browser after a failed job to tell a crash apart from a
+// page that merely failed to load
+func (p *BrowserPool) alive(b *browser) bool {
+	if b.ctx.Err() != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(b.ctx, 5*time.Second)
+	defer cancel()
+	_, err := chromedp.Targets(ctx)
+	return err == nil
+}
+
+// liveCount returns the browsers still accepting tabs; callers hold p.mu
+func (p *BrowserPool) liveCount() int {
+	n := 0
+	for _, b := range p.browsers {
+		if !b.retiring {
+			n++
+		}
+	}
+	return n
+}
+
+// remove drops b from the pool; callers hold p.mu
+func (p *BrowserPool) remove(b *browser) {
+	for i, other := range p.browsers {
+		if other == b {
+			p.browsers = append(p.browsers[:i], p.browsers[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBrowsers stands in for Chrome and records each launch
+type fakeBrowsers struct {
+	mu       sync.Mutex
+	launched []context.Context
+}
+
+func (f *fakeBrowsers) start(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	browserCtx, cancel := context.WithCancel(context.Background())
+	f.launched = append(f.launched, browserCtx)
+	return browserCtx, cancel, nil
+}
+
+func (f *fakeBrowsers) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.launched)
+}
+
+func newTestPool(size, maxPages int, start func(context.Context) (context.Context, context.CancelFunc, error)) *BrowserPool {
+	p := NewBrowserPool(size, maxPages)
+	p.start = start
+	p.probe = func(b *browser) bool { return b.ctx.Err() == nil }
+	return p
+}
+
+func TestBrowserPool_RecyclesAfterMaxPages(t *testing.T) {
+	fake := &fakeBrowsers{}
+	p := newTestPool(1, 2, fake.start)
+	defer p.Close()
+
+	first, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	second, _ := p.acquire(context.Background())
+	if second != first {
+		t.Fatal("expected a pool of one to share its browser")
+	}
+	p.release(first, nil)
+	if first.ctx.Err() != nil {
+		t.Fatal("expected the browser to stay up below its page limit")
+	}
+	// The page limit is reached while a tab is still open, which must finish first
+	third, _ := p.acquire(context.Background())
+	p.release(second, nil)
+	if third != first || first.ctx.Err() != nil {
+		t.Fatal("expected the retiring browser to wait for its open tab")
+	}
+	p.release(third, nil)
+	if first.ctx.Err() == nil {
+		t.Fatal("expected the browser to be shut down after its page limit")
+	}
+
+	next, _ := p.acquire(context.Background())
+	if next == first || fake.count() != 2 {
+		t.Errorf("expected a new browser after recycling, got %d launches", fake.count())
+	}
+}
+
+func TestBrowserPool_RecyclesAfterCrash(t *testing.T) {
+	fake := &fakeBrowsers{}
+	p := newTestPool(1, 50, fake.start)
+	defer p.Close()
+
+	b, _ := p.acquire(context.Background())
+	// A page that failed to load leaves a healthy browser in the pool
+	p.release(b, errors.New("navigation failed"))
+	if again, _ := p.acquire(context.Background()); again != b {
+		t.Fatal("expected a failed page not to recycle a responding browser")
+	}
+
+	// Chrome went away: the probe fails and the browser is replaced
+	b.cancel()
+	p.release(b, errors.New("websocket closed"))
+	next, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	if next == b || fake.count() != 2 {
+		t.Errorf("expected a crashed browser to be replaced, got %d launches", fake.count())
+	}
+}
+
+func TestBrowserPool_LaunchDoesNotBlockPool(t *testing.T) {
+	fake := &fakeBrowsers{}
+	hung := make(chan struct{})
+	defer close(hung)
+	p := newTestPool(2, 50, func(ctx context.Context) (context.Context, context.CancelFunc, error) {
+		if fake.count() == 0 {
+			return fake.start(ctx)
+		}
+		// The second Chrome never comes up
+		select {
+		case <-hung:
+		case <-ctx.Done():
+		}
+		return nil, nil, ctx.Err()
+	})
+	defer p.Close()
+
+	running, _ := p.acquire(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	launched := make(chan error, 1)
+	go func() {
+		_, err := p.acquire(ctx)
+		launched <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// The pool stays usable while the launch hangs
+	released := make(chan struct{})
+	go func() {
+		p.release(running, nil)
+		close(released)
+	}()
+	select {
+	case <-released:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("release blocked behind a hanging launch")
+	}
+	select {
+	case err := <-launched:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the job's deadline to end the launch, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire ignored the job's context")
+	}
+}
@@ -0,0 +1,178 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"headlessBrowser-worker/domain/model"
+	"strings"
+	"sync"
+
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// maxStackFrames keeps deep recursion from bloating the result
+const maxStackFrames = 10
+
+// consoleRecorder collects console calls, uncaught exceptions and browser
+// log entries of a tab
+type consoleRecorder struct {
+	mu       sync.Mutex
+	messages []model.ConsoleMessage
+}
+
+// recordConsole must be called before navigating so early errors are kept
+func recordConsole(ctx context.Context) *consoleRecorder {
+	r := &consoleRecorder{}
+	chromedp.ListenTarget(ctx, r.handle)
+	return r
+}
+
+func (r *consoleRecorder) handle(ev any) {
+	var m model.ConsoleMessage
+	switch ev := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		level, ok := consoleLevel(ev.Type)
+		if !ok {
+			return
+		}
+		m = model.ConsoleMessage{Level: level, Source: "console", Message: formatArgs(ev.Args)}
+		setLocation(&m, ev.StackTrace)
+	case *runtime.EventExceptionThrown:
+		d := ev.ExceptionDetails
+		m = model.ConsoleMessage{
+			Level:   model.ConsoleError,
+			Source:  "exception",
+			Message: exceptionMessage(d),
+			URL:     d.URL,
+			Line:    int(d.LineNumber) + 1,
+			Column:  int(d.ColumnNumber) + 1,
+			Stack:   formatStack(d.StackTrace),
+		}
+	case *cdplog.EventEntryAdded:
+		e := ev.Entry
+		m = model.ConsoleMessage{
+			Level:   logLevel(e.Level),
+			Source:  string(e.Source),
+			Message: e.Text,
+			URL:     e.URL,
+			Stack:   formatStack(e.StackTrace),
+		}
+		// Resource errors carry no line at all
+		if e.LineNumber > 0 {
+			m.Line = int(e.LineNumber) + 1
+		}
+	default:
+		return
+	}
+
+	r.mu.Lock()
+	r.messages = append(r.messages, m)
+	r.mu.Unlock()
+}
+
+func (r *consoleRecorder) snapshot() []model.ConsoleMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]model.ConsoleMessage(nil), r.messages...)
+}
+
+// consoleLevel maps console API calls to levels; calls that only structure
+// the output, such as console.group, are dropped
+func consoleLevel(t runtime.APIType) (string, bool) {
+	switch t {
+	case runtime.APITypeError, runtime.APITypeAssert:
+		return model.ConsoleError, true
+	case runtime.APITypeWarning:
+		return model.ConsoleWarning, true
+	case runtime.APITypeInfo:
+		return model.ConsoleInfo, true
+	case runtime.APITypeDebug:
+		return model.ConsoleDebug, true
+	case runtime.APITypeLog, runtime.APITypeDir, runtime.APITypeDirxml, runtime.APITypeTable,
+		runtime.APITypeTrace, runtime.APITypeCount, runtime.APITypeTimeEnd:
+		return model.ConsoleLog, true
+	}
+	return "", false
+}
+
+func logLevel(l cdplog.Level) string {
+	switch l {
+	case cdplog.LevelError:
+		return model.ConsoleError
+	case cdplog.LevelWarning:
+		return model.ConsoleWarning
+	case cdplog.LevelVerbose:
+		return model.ConsoleDebug
+	}
+	return model.ConsoleInfo
+}
+
+// formatArgs renders console arguments roughly as DevTools prints them
+func formatArgs(args []*runtime.RemoteObject) string {
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		parts = append(parts, remoteObjectString(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+func remoteObjectString(o *runtime.RemoteObject) string {
+	switch {
+	case len(o.Value) > 0:
+		var s string
+		if json.Unmarshal(o.Value, &s) == nil {
+			return s
+		}
+		return string(o.Value)
+	case o.UnserializableValue != "":
+		return string(o.UnserializableValue)
+	case o.Description != "":
+		return o.Description
+	}
+	return string(o.Type)
+}
+
+// exceptionMessage prefers the thrown value's description, which includes
+// the error class, over the generic "Uncaught" text
+func exceptionMessage(d *runtime.ExceptionDetails) string {
+	if d.Exception != nil && d.Exception.Description != "" {
+		// The description repeats the stack after the first line
+		message, _, _ := strings.Cut(d.Exception.Description, "\n")
+		return message
+	}
+	return d.Text
+}
+
+// setLocation points m at the top frame of stack
+func setLocation(m *model.ConsoleMessage, stack *runtime.StackTrace) {
+	if stack == nil || len(stack.CallFrames) == 0 {
+		return
+	}
+	top := stack.CallFrames[0]
+	m.URL = top.URL
+	m.Line = int(top.LineNumber) + 1
+	m.Column = int(top.ColumnNumber) + 1
+	m.Stack = formatStack(stack)
+}
+
+func formatStack(stack *runtime.StackTrace) string {
+	if stack == nil {
+		return ""
+	}
+	var b strings.Builder
+	for i, f := range stack.CallFrames {
+		if i == maxStackFrames {
+			fmt.Fprintf(&b, "... %d more frames", len(stack.CallFrames)-i)
+			break
+		}
+		name := f.FunctionName
+		if name == "" {
+			name = "<anonymous>"
+		}
+		fmt.Fprintf(&b, "at %s (%s:%d:%d)\n", name, f.URL, f.LineNumber+1, f.ColumnNumber+1)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
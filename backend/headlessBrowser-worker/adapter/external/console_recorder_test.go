@@ -0,0 +1,44 @@
+package external
+
+import (
+	"testing"
+
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestConsoleRecorder(t *testing.T) {
+	r := &consoleRecorder{}
+	stack := &runtime.StackTrace{CallFrames: []*runtime.CallFrame{
+		{FunctionName: "init", URL: "https://example.com/app.js", LineNumber: 41, ColumnNumber: 4},
+	}}
+
+	r.handle(&runtime.EventConsoleAPICalled{
+		Type:       runtime.APITypeError,
+		Args:       []*runtime.RemoteObject{{Type: runtime.TypeString, Value: []byte(`"failed to load"`)}, {Type: runtime.TypeNumber, Value: []byte(`42`)}},
+		StackTrace: stack,
+	})
+	r.handle(&runtime.EventConsoleAPICalled{Type: runtime.APITypeStartGroup})
+	r.handle(&runtime.EventExceptionThrown{ExceptionDetails: &runtime.ExceptionDetails{
+		Text: "Uncaught", URL: "https://example.com/app.js", LineNumber: 9, ColumnNumber: 2,
+		Exception:  &runtime.RemoteObject{Type: runtime.TypeObject, Description: "TypeError: x is undefined\n    at init (app.js:10:3)"},
+		StackTrace: stack,
+	}})
+	r.handle(&cdplog.EventEntryAdded{Entry: &cdplog.Entry{
+		Source: cdplog.SourceNetwork, Level: cdplog.LevelError, Text: "Failed to load resource: 404", URL: "https://example.com/logo.png",
+	}})
+
+	messages := r.snapshot()
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages (groups dropped), got %d: %+v", len(messages), messages)
+	}
+	if m := messages[0]; m.Message != "failed to load 42" || m.Line != 42 || m.Column != 5 || m.Stack != "at init (https://example.com/app.js:42:5)" {
+		t.Errorf("unexpected console message %+v", m)
+	}
+	if m := messages[1]; m.Source != "exception" || m.Message != "TypeError: x is undefined" || m.Line != 10 {
+		t.Errorf("unexpected exception %+v", m)
+	}
+	if m := messages[2]; m.Source != "network" || m.Level != "error" || m.Line != 0 {
+		t.Errorf("unexpected log entry %+v", m)
+	}
+}
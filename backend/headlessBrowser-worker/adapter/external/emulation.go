@@ -0,0 +1,61 @@
+package external
+
+import (
+	"context"
+	"headlessBrowser-worker/domain/model"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+)
+
+// defaultUserAgent is a realistic desktop Chrome, since some sites answer
+// 403 Forbidden to anything that looks like a bot
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// emulate applies e to the tab through CDP; it must run before navigating
+func emulate(e model.Emulation) chromedp.Action {
+	tasks := chromedp.Tasks{
+		emulation.SetDeviceMetricsOverride(int64(e.Viewport.Width), int64(e.Viewport.Height), e.DeviceScaleFactor, e.Mobile),
+	}
+	if e.Mobile {
+		tasks = append(tasks, emulation.SetTouchEmulationEnabled(true))
+	}
+
+	if e.UserAgent != "" || e.AcceptLanguage != "" {
+		userAgent := e.UserAgent
+		if userAgent == "" {
+			// The override replaces both, so keep the default agent
+			userAgent = defaultUserAgent
+		}
+		// Sets the Accept-Language header as well as navigator.languages
+		tasks = append(tasks, emulation.SetUserAgentOverride(userAgent).WithAcceptLanguage(e.AcceptLanguage))
+	}
+	if locale := e.Locale(); locale != "" {
+		// Makes Intl and date formatting follow the language
+		tasks = append(tasks, emulation.SetLocaleOverride().WithLocale(locale))
+	}
+	if e.Timezone != "" {
+		tasks = append(tasks, emulation.SetTimezoneOverride(e.Timezone))
+	}
+	if g := e.Geolocation; g != nil {
+		tasks = append(tasks,
+			grantGeolocation(),
+			emulation.SetGeolocationOverride().WithLatitude(g.Latitude).WithLongitude(g.Longitude).WithAccuracy(g.Accuracy),
+		)
+	}
+	return tasks
+}
+
+// grantGeolocation lets the page read the emulated position without a
+// permission prompt. Permissions belong to the browser, so the command is
+// sent there, scoped to the tab's incognito context.
+func grantGeolocation() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		c := chromedp.FromContext(ctx)
+		return cdpbrowser.GrantPermissions([]cdpbrowser.PermissionType{cdpbrowser.PermissionTypeGeolocation}).
+			WithBrowserContextID(c.BrowserContextID).
+			Do(cdp.WithExecutor(ctx, c.Browser))
+	})
+}
@@ -0,0 +1,244 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"headlessBrowser-worker/domain/model"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// pendingRequest is a request seen on the Network domain whose entry is not
+// complete yet
+type pendingRequest struct {
+	entry model.NetworkEntry
+	// startedAt is the CDP monotonic time of requestWillBeSent in seconds
+	startedAt float64
+	timing    *network.ResourceTiming
+	responded bool
+}
+
+// networkRecorder follows the Network domain of a tab and keeps one entry
+// per request, including each redirect hop
+type networkRecorder struct {
+	mu       sync.Mutex
+	pending  map[network.RequestID]*pendingRequest
+	entries  []model.NetworkEntry
+	navStart float64
+	domReady float64
+	loaded   float64
+}
+
+// recordNetwork must be called before navigating so no request is missed
+func recordNetwork(ctx context.Context) *networkRecorder {
+	r := &networkRecorder{pending: make(map[network.RequestID]*pendingRequest)}
+	chromedp.ListenTarget(ctx, r.handle)
+	return r
+}
+
+func (r *networkRecorder) handle(ev any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		if p, ok := r.pending[ev.RequestID]; ok && ev.RedirectResponse != nil {
+			// The same request ID continues after a redirect; close the hop
+			r.respond(p, ev.RedirectResponse)
+			p.entry.RedirectURL = ev.Request.URL
+			r.complete(ev.RequestID, monotonic(ev.Timestamp))
+		}
+		started := monotonic(ev.Timestamp)
+		if r.navStart == 0 {
+			r.navStart = started
+		}
+		r.pending[ev.RequestID] = &pendingRequest{
+			startedAt: started,
+			entry: model.NetworkEntry{
+				URL:            ev.Request.URL + ev.Request.URLFragment,
+				Method:         ev.Request.Method,
+				ResourceType:   string(ev.Type),
+				Initiator:      initiator(ev.Initiator),
+				StartedAt:      wallTime(ev.WallTime),
+				RequestHeaders: headers(ev.Request.Headers),
+				HasPostData:    ev.Request.HasPostData,
+			},
+		}
+	case *network.EventResponseReceived:
+		if p, ok := r.pending[ev.RequestID]; ok {
+			r.respond(p, ev.Response)
+		}
+	case *network.EventRequestServedFromCache:
+		if p, ok := r.pending[ev.RequestID]; ok {
+			p.entry.FromCache = true
+		}
+	case *network.EventDataReceived:
+		if p, ok := r.pending[ev.RequestID]; ok {
+			p.entry.ContentSize += ev.DataLength
+		}
+	case *network.EventLoadingFinished:
+		if p, ok := r.pending[ev.RequestID]; ok {
+			p.entry.TransferSize = int64(ev.EncodedDataLength)
+			r.complete(ev.RequestID, monotonic(ev.Timestamp))
+		}
+	case *network.EventLoadingFailed:
+		if p, ok := r.pending[ev.RequestID]; ok {
+			p.entry.Error = ev.ErrorText
+			if ev.BlockedReason != "" {
+				p.entry.Error = fmt.Sprintf("%s (%s)", ev.ErrorText, ev.BlockedReason)
+			}
+			r.complete(ev.RequestID, monotonic(ev.Timestamp))
+		}
+	case *page.EventDomContentEventFired:
+		if r.domReady == 0 {
+			r.domReady = monotonic(ev.Timestamp)
+		}
+	case *page.EventLoadEventFired:
+		if r.loaded == 0 {
+			r.loaded = monotonic(ev.Timestamp)
+		}
+	}
+}
+
+// respond copies the response into the entry; callers hold r.mu
+func (r *networkRecorder) respond(p *pendingRequest, resp *network.Response) {
+	p.responded = true
+	p.timing = resp.Timing
+	p.entry.Status = int(resp.Status)
+	p.entry.StatusText = resp.StatusText
+	p.entry.Protocol = resp.Protocol
+	p.entry.MimeType = resp.MimeType
+	p.entry.ResponseHeaders = headers(resp.Headers)
+	p.entry.RemoteIP = resp.RemoteIPAddress
+	p.entry.TransferSize = int64(resp.EncodedDataLength)
+	if resp.FromDiskCache || resp.FromServiceWorker || resp.FromPrefetchCache {
+		p.entry.FromCache = true
+	}
+}
+
+// complete moves a finished request to the entries; callers hold r.mu
+func (r *networkRecorder) complete(id network.RequestID, finishedAt float64) {
+	p := r.pending[id]
+	delete(r.pending, id)
+	p.entry.Timings = timings(p, finishedAt)
+	r.entries = append(r.entries, p.entry)
+}
+
+// snapshot returns the entries recorded so far in start order, including
+// requests that got a response but are still streaming. Requests still
+// waiting for a response are left out.
+func (r *networkRecorder) snapshot() ([]model.NetworkEntry, model.PageTimings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := append([]model.NetworkEntry(nil), r.entries...)
+	for _, p := range r.pending {
+		if p.responded {
+			entry := p.entry
+			entry.Timings = timings(p, 0)
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].StartedAt.Before(entries[j].StartedAt) })
+
+	pageTimings := model.PageTimings{DOMContentLoaded: -1, Load: -1}
+	if r.domReady > 0 {
+		pageTimings.DOMContentLoaded = (r.domReady - r.navStart) * 1000
+	}
+	if r.loaded > 0 {
+		pageTimings.Load = (r.loaded - r.navStart) * 1000
+	}
+	return entries, pageTimings
+}
+
+// timings converts Chrome's resource timing, offsets in ms from
+// requestTime, into HAR phases
+func timings(p *pendingRequest, finishedAt float64) model.Timings {
+	t := model.Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	rt := p.timing
+	if rt == nil {
+		// No response (failed or from memory cache): only the total is known
+		if finishedAt > p.startedAt {
+			t.Wait = (finishedAt - p.startedAt) * 1000
+		}
+		return t
+	}
+
+	// Queueing before Chrome started the request counts as blocked
+	blocked := (rt.RequestTime - p.startedAt) * 1000
+	if first := firstNonNegative(rt.DNSStart, rt.ConnectStart, rt.SendStart); first > 0 {
+		blocked += first
+	}
+	if blocked > 0 {
+		t.Blocked = blocked
+	}
+	if rt.DNSStart >= 0 {
+		t.DNS = rt.DNSEnd - rt.DNSStart
+	}
+	if rt.ConnectStart >= 0 {
+		t.Connect = rt.ConnectEnd - rt.ConnectStart
+	}
+	if rt.SslStart >= 0 {
+		t.SSL = rt.SslEnd - rt.SslStart
+	}
+	t.Send = rt.SendEnd - rt.SendStart
+	t.Wait = rt.ReceiveHeadersEnd - rt.SendEnd
+	if finishedAt > 0 {
+		if receive := (finishedAt-rt.RequestTime)*1000 - rt.ReceiveHeadersEnd; receive > 0 {
+			t.Receive = receive
+		}
+	}
+	return t
+}
+
+func firstNonNegative(values ...float64) float64 {
+	for _, v := range values {
+		if v >= 0 {
+			return v
+		}
+	}
+	return -1
+}
+
+func initiator(i *network.Initiator) model.Initiator {
+	if i == nil {
+		return model.Initiator{Type: "other"}
+	}
+	out := model.Initiator{Type: string(i.Type), URL: i.URL, Line: int(i.LineNumber)}
+	// Script-initiated requests only carry a stack; its top frame is the caller
+	if out.URL == "" && i.Stack != nil && len(i.Stack.CallFrames) > 0 {
+		frame := i.Stack.CallFrames[0]
+		out.URL = frame.URL
+		out.Line = int(frame.LineNumber) + 1
+	}
+	return out
+}
+
+func headers(h network.Headers) []model.Header {
+	out := make([]model.Header, 0, len(h))
+	for name, value := range h {
+		out = append(out, model.Header{Name: name, Value: fmt.Sprint(value)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func monotonic(t *cdp.MonotonicTime) float64 {
+	if t == nil || cdp.MonotonicTimeEpoch == nil {
+		return 0
+	}
+	return t.Time().Sub(*cdp.MonotonicTimeEpoch).Seconds()
+}
+
+func wallTime(t *cdp.TimeSinceEpoch) time.Time {
+	if t == nil {
+		return time.Now()
+	}
+	return t.Time()
+}
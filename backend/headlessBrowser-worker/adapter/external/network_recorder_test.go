@@ -0,0 +1,82 @@
+package external
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+)
+
+func at(seconds float64) *cdp.MonotonicTime {
+	t := cdp.MonotonicTime(cdp.MonotonicTimeEpoch.Add(time.Duration(seconds * float64(time.Second))))
+	return &t
+}
+
+func wall(seconds float64) *cdp.TimeSinceEpoch {
+	t := cdp.TimeSinceEpoch(time.Unix(1700000000, 0).Add(time.Duration(seconds * float64(time.Second))))
+	return &t
+}
+
+func TestNetworkRecorder_RedirectsAndFailures(t *testing.T) {
+	r := &networkRecorder{pending: make(map[network.RequestID]*pendingRequest)}
+
+	r.handle(&network.EventRequestWillBeSent{
+		RequestID: "doc", Timestamp: at(100), WallTime: wall(0), Type: network.ResourceTypeDocument,
+		Request: &network.Request{URL: "http://example.com/", Method: "GET"},
+	})
+	// Chrome reuses the request ID for the redirected request
+	r.handle(&network.EventRequestWillBeSent{
+		RequestID: "doc", Timestamp: at(100.05), WallTime: wall(0.05), Type: network.ResourceTypeDocument,
+		Request:          &network.Request{URL: "https://example.com/", Method: "GET"},
+		RedirectResponse: &network.Response{Status: 301, StatusText: "Moved Permanently"},
+	})
+	r.handle(&network.EventResponseReceived{RequestID: "doc", Response: &network.Response{
+		Status: 200, Protocol: "h2", MimeType: "text/html",
+		Headers: network.Headers{"Content-Type": "text/html"},
+		Timing: &network.ResourceTiming{
+			RequestTime: 100.06, DNSStart: -1, DNSEnd: -1, ConnectStart: 0, ConnectEnd: 30, SslStart: 10, SslEnd: 30,
+			SendStart: 31, SendEnd: 32, ReceiveHeadersEnd: 82,
+		},
+	}})
+	r.handle(&network.EventDataReceived{RequestID: "doc", DataLength: 4000})
+	r.handle(&network.EventLoadingFinished{RequestID: "doc", Timestamp: at(100.2), EncodedDataLength: 1500})
+	r.handle(&page.EventDomContentEventFired{Timestamp: at(100.5)})
+
+	r.handle(&network.EventRequestWillBeSent{
+		RequestID: "img", Timestamp: at(100.3), WallTime: wall(0.3), Type: network.ResourceTypeImage,
+		Request:   &network.Request{URL: "https://example.com/missing.png", Method: "GET"},
+		Initiator: &network.Initiator{Type: network.InitiatorTypeParser, URL: "https://example.com/", LineNumber: 4},
+	})
+	r.handle(&network.EventLoadingFailed{RequestID: "img", Timestamp: at(100.4), ErrorText: "net::ERR_FAILED"})
+	// Never answered, so left out of the snapshot
+	r.handle(&network.EventRequestWillBeSent{
+		RequestID: "xhr", Timestamp: at(100.6), WallTime: wall(0.6), Type: network.ResourceTypeXHR,
+		Request: &network.Request{URL: "https://example.com/poll", Method: "GET"},
+	})
+
+	entries, pageTimings := r.snapshot()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(entries), entries)
+	}
+	redirect, doc, img := entries[0], entries[1], entries[2]
+	if redirect.Status != 301 || redirect.RedirectURL != "https://example.com/" {
+		t.Errorf("unexpected redirect hop %+v", redirect)
+	}
+	if doc.Status != 200 || doc.TransferSize != 1500 || doc.ContentSize != 4000 || len(doc.ResponseHeaders) != 1 {
+		t.Errorf("unexpected document entry %+v", doc)
+	}
+	if doc.Timings.DNS != -1 || doc.Timings.Connect != 30 || doc.Timings.SSL != 20 || doc.Timings.Wait != 50 {
+		t.Errorf("unexpected document timings %+v", doc.Timings)
+	}
+	if receive := doc.Timings.Receive; receive < 57 || receive > 59 {
+		t.Errorf("expected about 58ms receive, got %v", receive)
+	}
+	if img.Error != "net::ERR_FAILED" || img.Initiator.Line != 4 {
+		t.Errorf("unexpected failed entry %+v", img)
+	}
+	if dcl := pageTimings.DOMContentLoaded; dcl < 499 || dcl > 501 || pageTimings.Load != -1 {
+		t.Errorf("unexpected page timings %+v", pageTimings)
+	}
+}
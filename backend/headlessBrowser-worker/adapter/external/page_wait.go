@@ -0,0 +1,203 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"headlessBrowser-worker/domain/model"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+const scrollToBottom = `window.scrollTo(0, document.body ? document.body.scrollHeight : 0)`
+
+const (
+	// lazyLoadIdle is how long the network must stay quiet after the scroll
+	// for lazy-loaded content to count as loaded
+	lazyLoadIdle = 500 * time.Millisecond
+	// lazyLoadTimeout caps that wait on pages that never go quiet
+	lazyLoadTimeout = 3 * time.Second
+)
+
+// pageEvents follows the lifecycle events and network activity of a tab so
+// readiness can be decided without polling the page
+type pageEvents struct {
+	domReady chan struct{}
+	loaded   chan struct{}
+	// changed is signalled whenever a request starts or ends
+	changed chan struct{}
+
+	mu       sync.Mutex
+	inflight map[network.RequestID]bool
+	domOnce  sync.Once
+	loadOnce sync.Once
+}
+
+// listenPageEvents must be called before navigating so no event is missed
+func listenPageEvents(ctx context.Context) *pageEvents {
+	e := &pageEvents{
+		domReady: make(chan struct{}),
+		loaded:   make(chan struct{}),
+		changed:  make(chan struct{}, 1),
+		inflight: make(map[network.RequestID]bool),
+	}
+	chromedp.ListenTarget(ctx, e.handle)
+	return e
+}
+
+func (e *pageEvents) handle(ev any) {
+	switch ev := ev.(type) {
+	case *page.EventDomContentEventFired:
+		e.domOnce.Do(func() { close(e.domReady) })
+	case *page.EventLoadEventFired:
+		e.loadOnce.Do(func() { close(e.loaded) })
+	case *network.EventRequestWillBeSent:
+		// Server-sent event streams never finish, so they can't block idleness
+		if ev.Type == network.ResourceTypeEventSource {
+			return
+		}
+		e.track(ev.RequestID, true)
+	case *network.EventLoadingFinished:
+		e.track(ev.RequestID, false)
+	case *network.EventLoadingFailed:
+		e.track(ev.RequestID, false)
+	}
+}
+
+func (e *pageEvents) track(id network.RequestID, started bool) {
+	e.mu.Lock()
+	if started {
+		e.inflight[id] = true
+	} else {
+		delete(e.inflight, id)
+	}
+	e.mu.Unlock()
+	select {
+	case e.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (e *pageEvents) pending() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.inflight)
+}
+
+// waitNetworkIdle returns once no request has been in flight for idle
+func (e *pageEvents) waitNetworkIdle(ctx context.Context, idle time.Duration) error {
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+	for {
+		if e.pending() == 0 {
+			timer.Reset(idle)
+		} else {
+			timer.Stop()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-e.changed:
+		case <-timer.C:
+			return nil
+		}
+	}
+}
+
+// waitReady blocks until the condition of w is met or its timeout expires.
+// A timeout or a failing condition is recorded in the returned Readiness
+// rather than failing the render, so the page is still captured as it is;
+// only cancellation of ctx is returned as an error.
+func waitReady(ctx context.Context, w model.WaitOptions, events *pageEvents) (model.Readiness, error) {
+	start := time.Now()
+	waitCtx, cancel := context.WithTimeout(ctx, w.Timeout())
+	defer cancel()
+
+	var condition string
+	var err error
+	switch w.Strategy {
+	case model.WaitNetworkIdle:
+		condition = fmt.Sprintf("network idle for %dms", w.IdleMS)
+		if err = waitClosed(waitCtx, events.domReady); err == nil {
+			// Scrolling first lets lazy-loaded content count as network activity
+			err = chromedp.Run(waitCtx, chromedp.Evaluate(scrollToBottom, nil))
+		}
+		if err == nil {
+			err = events.waitNetworkIdle(waitCtx, time.Duration(w.IdleMS)*time.Millisecond)
+		}
+	case model.WaitSelector:
+		condition = fmt.Sprintf("selector %q visible", w.Selector)
+		err = chromedp.Run(waitCtx, chromedp.WaitVisible(w.Selector, chromedp.ByQuery))
+	case model.WaitExpression:
+		condition = "expression truthy"
+		err = chromedp.Run(waitCtx, chromedp.Poll(w.Expression, nil,
+			chromedp.WithPollingInterval(100*time.Millisecond),
+			chromedp.WithPollingTimeout(0)))
+	case model.WaitDOMContentLoaded:
+		condition = "DOMContentLoaded fired"
+		err = waitClosed(waitCtx, events.domReady)
+	case model.WaitLoad:
+		condition = "load fired"
+		err = waitClosed(waitCtx, events.loaded)
+	case model.WaitDelay:
+		condition = fmt.Sprintf("waited %dms", w.DelayMS)
+		err = sleep(waitCtx, time.Duration(w.DelayMS)*time.Millisecond)
+	default:
+		err = fmt.Errorf("unknown wait strategy %q", w.Strategy)
+	}
+
+	readiness := model.Readiness{Strategy: w.Strategy, Condition: condition}
+	switch {
+	case ctx.Err() != nil:
+		return readiness, ctx.Err()
+	case waitCtx.Err() != nil:
+		readiness.TimedOut = true
+		readiness.Condition = fmt.Sprintf("timed out after %dms waiting for %s", w.TimeoutMS, condition)
+	case err != nil:
+		readiness.Condition = "wait failed: " + err.Error()
+	}
+	readiness.WaitedMS = time.Since(start).Milliseconds()
+	return readiness, nil
+}
+
+// scrollAndSettle scrolls to the bottom and waits for the requests this
+// triggers, so lazy-loaded content is in the captured DOM. Only
+// cancellation of ctx is returned as an error.
+func scrollAndSettle(ctx context.Context, events *pageEvents) error {
+	if err := chromedp.Run(ctx, chromedp.Evaluate(scrollToBottom, nil)); err != nil {
+		return err
+	}
+	return events.waitLazyLoad(ctx)
+}
+
+// waitLazyLoad waits for the network to go quiet for lazyLoadIdle, giving up
+// after lazyLoadTimeout
+func (e *pageEvents) waitLazyLoad(ctx context.Context) error {
+	settleCtx, cancel := context.WithTimeout(ctx, lazyLoadTimeout)
+	defer cancel()
+	e.waitNetworkIdle(settleCtx, lazyLoadIdle)
+	return ctx.Err()
+}
+
+func waitClosed(ctx context.Context, ch <-chan struct{}) error {
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,71 @@
+package external
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func newTestPageEvents() *pageEvents {
+	return &pageEvents{
+		domReady: make(chan struct{}),
+		loaded:   make(chan struct{}),
+		changed:  make(chan struct{}, 1),
+		inflight: make(map[network.RequestID]bool),
+	}
+}
+
+func TestWaitNetworkIdle_WaitsForInflightRequests(t *testing.T) {
+	events := newTestPageEvents()
+	events.handle(&network.EventRequestWillBeSent{RequestID: "1", Type: network.ResourceTypeScript})
+	// An event stream stays open forever and must not keep the page busy
+	events.handle(&network.EventRequestWillBeSent{RequestID: "2", Type: network.ResourceTypeEventSource})
+
+	finished := time.Now().Add(100 * time.Millisecond)
+	go func() {
+		time.Sleep(time.Until(finished))
+		events.handle(&network.EventLoadingFinished{RequestID: "1"})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := events.waitNetworkIdle(ctx, 50*time.Millisecond); err != nil {
+		t.Fatalf("expected the network to go idle, got %v", err)
+	}
+	if time.Now().Before(finished.Add(50 * time.Millisecond)) {
+		t.Error("network reported idle while a request was still in flight")
+	}
+}
+
+func TestWaitNetworkIdle_TimesOut(t *testing.T) {
+	events := newTestPageEvents()
+	events.handle(&network.EventRequestWillBeSent{RequestID: "1", Type: network.ResourceTypeXHR})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := events.waitNetworkIdle(ctx, 10*time.Millisecond); err == nil {
+		t.Fatal("expected a timeout while a request never finishes")
+	}
+}
+
+func TestWaitLazyLoad_GivesUpOnBusyPages(t *testing.T) {
+	events := newTestPageEvents()
+	// A request that never finishes, like a long poll
+	events.handle(&network.EventRequestWillBeSent{RequestID: "1", Type: network.ResourceTypeXHR})
+
+	start := time.Now()
+	if err := events.waitLazyLoad(context.Background()); err != nil {
+		t.Fatalf("expected a busy page to be captured anyway, got %v", err)
+	}
+	if waited := time.Since(start); waited < lazyLoadTimeout || waited > lazyLoadTimeout+time.Second {
+		t.Errorf("expected to wait about %v, waited %v", lazyLoadTimeout, waited)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := events.waitLazyLoad(ctx); err == nil {
+		t.Error("expected a cancelled render to fail")
+	}
+}
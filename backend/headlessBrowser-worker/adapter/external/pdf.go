@@ -0,0 +1,49 @@
+package external
+
+import (
+	"context"
+	"headlessBrowser-worker/domain/model"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// printPDF prints the tab with Page.printToPDF as configured by opts
+func printPDF(opts model.PDFOptions, pdf *[]byte) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		width, height := opts.PaperSize()
+		params := page.PrintToPDF().
+			WithPaperWidth(width).
+			WithPaperHeight(height).
+			WithLandscape(opts.Landscape).
+			WithPrintBackground(opts.PrintBackground).
+			WithPageRanges(opts.PageRanges)
+		if opts.Scale != 0 {
+			params = params.WithScale(opts.Scale)
+		}
+		if m := opts.Margin; m != nil {
+			params = params.WithMarginTop(m.Top).WithMarginBottom(m.Bottom).WithMarginLeft(m.Left).WithMarginRight(m.Right)
+		}
+		if opts.HeaderTemplate != "" || opts.FooterTemplate != "" {
+			// Chrome prints its default template for whichever one is empty
+			params = params.
+				WithDisplayHeaderFooter(true).
+				WithHeaderTemplate(orEmptySpan(opts.HeaderTemplate)).
+				WithFooterTemplate(orEmptySpan(opts.FooterTemplate))
+		}
+
+		data, _, err := params.Do(ctx)
+		if err != nil {
+			return err
+		}
+		*pdf = data
+		return nil
+	})
+}
+
+func orEmptySpan(template string) string {
+	if template == "" {
+		return "<span></span>"
+	}
+	return template
+}
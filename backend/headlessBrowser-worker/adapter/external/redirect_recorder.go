@@ -0,0 +1,95 @@
+package external
+
+import (
+	"context"
+	"headlessBrowser-worker/domain/model"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// redirectRecorder follows the documents loaded into the main frame of a
+// tab: every document that is replaced by another one is a redirect hop
+type redirectRecorder struct {
+	mu        sync.Mutex
+	mainFrame cdp.FrameID
+	hops      []model.RedirectHop
+	// current is the document loaded last, requested as currentID
+	current   *model.RedirectHop
+	currentID network.RequestID
+	// reason is why the page asked for the next navigation, if it did
+	reason page.ClientNavigationReason
+}
+
+// recordRedirects must be called before navigating; the first document
+// requested is taken as the main frame
+func recordRedirects(ctx context.Context) *redirectRecorder {
+	r := &redirectRecorder{}
+	chromedp.ListenTarget(ctx, r.handle)
+	return r
+}
+
+func (r *redirectRecorder) handle(ev any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *page.EventFrameRequestedNavigation:
+		if ev.FrameID == r.mainFrame {
+			r.reason = ev.Reason
+		}
+	case *network.EventRequestWillBeSent:
+		if ev.Type != network.ResourceTypeDocument {
+			return
+		}
+		if r.mainFrame == "" {
+			r.mainFrame = ev.FrameID
+		} else if ev.FrameID != r.mainFrame {
+			return
+		}
+		if r.current != nil {
+			hop := *r.current
+			hop.Location = ev.Request.URL
+			if ev.RedirectResponse != nil && ev.RequestID == r.currentID {
+				hop.Status = int(ev.RedirectResponse.Status)
+				hop.Type = model.RedirectHTTP
+			} else {
+				hop.Type = redirectType(r.reason)
+			}
+			r.hops = append(r.hops, hop)
+		}
+		r.current = &model.RedirectHop{URL: ev.Request.URL}
+		r.currentID = ev.RequestID
+		r.reason = ""
+	case *network.EventResponseReceived:
+		if r.current != nil && ev.RequestID == r.currentID {
+			r.current.Status = int(ev.Response.Status)
+		}
+	}
+}
+
+// snapshot returns the hops so far and the URL and status of the document
+// loaded last
+func (r *redirectRecorder) snapshot() (hops []model.RedirectHop, finalURL string, status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current == nil {
+		return nil, "", 0
+	}
+	return append([]model.RedirectHop(nil), r.hops...), r.current.URL, r.current.Status
+}
+
+func redirectType(reason page.ClientNavigationReason) string {
+	switch reason {
+	case page.ClientNavigationReasonMetaTagRefresh:
+		return model.RedirectMetaRefresh
+	case page.ClientNavigationReasonHTTPHeaderRefresh:
+		return model.RedirectHTTP
+	case page.ClientNavigationReasonScriptInitiated:
+		return model.RedirectJS
+	}
+	return model.RedirectOther
+}
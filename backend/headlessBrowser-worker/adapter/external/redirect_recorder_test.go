@@ -0,0 +1,54 @@
+package external
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+func TestRedirectRecorder(t *testing.T) {
+	r := &redirectRecorder{}
+	document := func(id network.RequestID, frame, url string, redirect *network.Response) {
+		r.handle(&network.EventRequestWillBeSent{
+			RequestID: id, FrameID: cdp.FrameID(frame), Type: network.ResourceTypeDocument,
+			Request: &network.Request{URL: url}, RedirectResponse: redirect,
+		})
+	}
+	respond := func(id network.RequestID, status int64) {
+		r.handle(&network.EventResponseReceived{RequestID: id, Response: &network.Response{Status: status}})
+	}
+
+	document("1", "main", "http://example.com/", nil)
+	document("1", "main", "https://example.com/", &network.Response{Status: 301})
+	respond("1", 200)
+	// An iframe loading does not move the main document
+	document("2", "ad", "https://ads.example.net/frame", nil)
+	r.handle(&page.EventFrameRequestedNavigation{FrameID: "main", Reason: page.ClientNavigationReasonMetaTagRefresh})
+	document("3", "main", "https://example.com/home", nil)
+	respond("3", 200)
+	r.handle(&page.EventFrameRequestedNavigation{FrameID: "main", Reason: page.ClientNavigationReasonScriptInitiated})
+	document("4", "main", "https://www.example.com/home", nil)
+	respond("4", 404)
+
+	hops, finalURL, status := r.snapshot()
+	want := []model.RedirectHop{
+		{URL: "http://example.com/", Status: 301, Location: "https://example.com/", Type: model.RedirectHTTP},
+		{URL: "https://example.com/", Status: 200, Location: "https://example.com/home", Type: model.RedirectMetaRefresh},
+		{URL: "https://example.com/home", Status: 200, Location: "https://www.example.com/home", Type: model.RedirectJS},
+	}
+	if len(hops) != len(want) {
+		t.Fatalf("expected %d hops, got %+v", len(want), hops)
+	}
+	for i := range want {
+		if hops[i] != want[i] {
+			t.Errorf("hop %d: expected %+v, got %+v", i, want[i], hops[i])
+		}
+	}
+	if finalURL != "https://www.example.com/home" || status != 404 {
+		t.Errorf("unexpected final document %s %d", finalURL, status)
+	}
+}
@@ -0,0 +1,51 @@
+package external
+
+import (
+	"context"
+	"headlessBrowser-worker/domain/model"
+	"math"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// maxCaptureHeight keeps full-page captures under Chrome's texture limit
+const maxCaptureHeight = 16384
+
+// captureScreenshot captures the tab as configured by opts into shot
+func captureScreenshot(opts model.ScreenshotOptions, shot *model.Screenshot) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		opts = opts.WithDefaults()
+		capture := page.CaptureScreenshot().WithFormat(page.CaptureScreenshotFormatPng)
+		if opts.Format == model.ScreenshotJPEG {
+			capture = capture.WithFormat(page.CaptureScreenshotFormatJpeg).WithQuality(int64(opts.Quality))
+		}
+
+		switch {
+		case opts.Clip != nil:
+			c := opts.Clip
+			capture = capture.
+				WithClip(&page.Viewport{X: c.X, Y: c.Y, Width: c.Width, Height: c.Height, Scale: 1}).
+				WithCaptureBeyondViewport(true)
+		case !opts.ViewportOnly:
+			_, _, _, _, _, content, err := page.GetLayoutMetrics().Do(ctx)
+			if err != nil {
+				return err
+			}
+			capture = capture.
+				WithClip(&page.Viewport{
+					Width:  math.Ceil(content.Width),
+					Height: math.Min(math.Ceil(content.Height), maxCaptureHeight),
+					Scale:  1,
+				}).
+				WithCaptureBeyondViewport(true)
+		}
+
+		data, err := capture.Do(ctx)
+		if err != nil {
+			return err
+		}
+		*shot = model.Screenshot{Format: opts.Format, Data: data}
+		return nil
+	})
+}
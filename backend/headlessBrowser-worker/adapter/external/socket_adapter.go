@@ -2,24 +2,41 @@ package external
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"headlessBrowser-worker/domain/model"
 	"net/http"
+	"time"
 )
 
 type SocketAdapter struct {
 	Endpoint string
+	// Client bounds every publish with its Timeout, since events are
+	// published while the job holds a worker
+	Client *http.Client
 }
 
-func (s *SocketAdapter) Publish(result interface{}) error {
+func NewSocketAdapter(endpoint string, timeout time.Duration) *SocketAdapter {
+	return &SocketAdapter{Endpoint: endpoint, Client: &http.Client{Timeout: timeout}}
+}
+
+// Publish posts the event; the socket server routes it by its job_id
+func (s *SocketAdapter) Publish(ctx context.Context, event model.Event) error {
 	// 1. Check for Marshalling errors first
-	jsonData, err := json.Marshal(result)
+	jsonData, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal result: %w", err)
 	}
 
 	// 2. Perform the POST request
-	resp, err := http.Post(s.Endpoint, "application/json", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to build publish request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
 	if err != nil {
 		// This handles network-level errors (e.g., socket-service is down)
 		return fmt.Errorf("failed to reach Socket Service: %w", err)
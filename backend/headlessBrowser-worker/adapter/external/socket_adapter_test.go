@@ -0,0 +1,30 @@
+package external
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+func TestSocketAdapter_StalledServerTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	adapter := NewSocketAdapter(srv.URL, 50*time.Millisecond)
+	start := time.Now()
+	err := adapter.Publish(context.Background(), model.Event{JobID: "job-1", Type: model.EventRenderStarted})
+	if err == nil {
+		t.Fatal("expected a stalled socket server to fail the publish")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the publish to give up after its timeout, took %v", elapsed)
+	}
+}
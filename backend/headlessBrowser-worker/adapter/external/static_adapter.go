@@ -0,0 +1,246 @@
+package external
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"headlessBrowser-worker/domain/model"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// maxRedirects matches Chrome's limit
+const maxRedirects = 20
+
+var (
+	// ErrDocumentTooLarge is returned when a page exceeds the document size limit
+	ErrDocumentTooLarge = errors.New("document too large")
+	// ErrNotHTML is returned for a PDF, image or other non-document URL
+	ErrNotHTML = errors.New("document is not HTML or XML")
+)
+
+// StaticAdapter fetches pages over plain HTTP without running any script.
+// It follows redirects, decodes the document to UTF-8 and is bound by the
+// same navigation timeout as ChromeAdapter.
+type StaticAdapter struct {
+	Client *http.Client
+	// MaxBytes limits the size of the document; 0 means no limit
+	MaxBytes int64
+}
+
+func NewStaticAdapter(maxBytes int64) *StaticAdapter {
+	return &StaticAdapter{Client: &http.Client{}, MaxBytes: maxBytes}
+}
+
+// GetRenderedHTML fetches targetURL as a browser would request a document.
+// Only the user agent and language of the emulation are applied.
+func (a *StaticAdapter) GetRenderedHTML(ctx context.Context, targetURL string, opts model.RenderOptions) (*model.RenderedPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, navigationTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	emulation := opts.EmulationOrDefault()
+	req.Header.Set("User-Agent", defaultUserAgent)
+	if emulation.UserAgent != "" {
+		req.Header.Set("User-Agent", emulation.UserAgent)
+	}
+	if emulation.AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", emulation.AcceptLanguage)
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	// Asked for explicitly so the transport leaves decompression to us and
+	// the bytes on the wire can be counted
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	start := time.Now()
+	hops := &hopRecorder{last: start}
+	client := *a.Client
+	client.CheckRedirect = hops.redirect
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	headersAt := time.Now()
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !isDocument(contentType) {
+		return nil, fmt.Errorf("%w: %s", ErrNotHTML, contentType)
+	}
+	wire := &countingReader{r: resp.Body}
+	body, err := a.readBody(wire, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+	if contentType == "" && !isDocument(http.DetectContentType(body)) {
+		return nil, fmt.Errorf("%w: %s", ErrNotHTML, http.DetectContentType(body))
+	}
+	final := hops.entry(resp, headersAt)
+	final.TransferSize = wire.n
+	final.Timings.Receive = millis(time.Since(headersAt))
+
+	doc, err := decode(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	final.ContentSize = int64(len(doc))
+
+	redirects := make([]model.RedirectHop, 0, len(hops.entries))
+	for _, hop := range hops.entries {
+		redirects = append(redirects, model.RedirectHop{URL: hop.URL, Status: hop.Status, Location: hop.RedirectURL, Type: model.RedirectHTTP})
+	}
+	return &model.RenderedPage{
+		HTML:      doc,
+		Mode:      model.RenderStatic,
+		FinalURL:  resp.Request.URL.String(),
+		Status:    resp.StatusCode,
+		Redirects: redirects,
+		Readiness: model.Readiness{
+			Condition: fmt.Sprintf("fetched over HTTP with status %d", resp.StatusCode),
+			WaitedMS:  time.Since(start).Milliseconds(),
+		},
+		Network:     append(hops.entries, final),
+		PageTimings: model.PageTimings{DOMContentLoaded: -1, Load: -1},
+	}, nil
+}
+
+// readBody uncompresses and reads the body before charset decoding, failing
+// once it passes MaxBytes
+func (a *StaticAdapter) readBody(wire io.Reader, contentEncoding string) ([]byte, error) {
+	r := wire
+	switch strings.ToLower(contentEncoding) {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(wire)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", contentEncoding)
+	}
+
+	if a.MaxBytes <= 0 {
+		return io.ReadAll(r)
+	}
+	body, err := io.ReadAll(io.LimitReader(r, a.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > a.MaxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrDocumentTooLarge, a.MaxBytes)
+	}
+	return body, nil
+}
+
+// isDocument tells whether a media type is HTML or XML, which browsers
+// render as a document
+func isDocument(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "text/html", "application/xhtml+xml", "text/xml", "application/xml":
+		return true
+	}
+	return false
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decode converts body to UTF-8 using the charset of the Content-Type
+// header, a BOM or a <meta> tag, in the order browsers check them
+func decode(body []byte, contentType string) (string, error) {
+	enc, _, _ := charset.DetermineEncoding(body, contentType)
+	doc, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return "", err
+	}
+	return string(doc), nil
+}
+
+// hopRecorder turns the redirects followed by http.Client into network
+// entries, one per hop
+type hopRecorder struct {
+	entries []model.NetworkEntry
+	// last is when the current hop was requested
+	last time.Time
+}
+
+func (h *hopRecorder) redirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	now := time.Now()
+	entry := h.entry(req.Response, now)
+	entry.RedirectURL = req.URL.String()
+	h.entries = append(h.entries, entry)
+	h.last = now
+	return nil
+}
+
+// entry describes the request that produced resp; respondedAt is when its
+// headers arrived
+func (h *hopRecorder) entry(resp *http.Response, respondedAt time.Time) model.NetworkEntry {
+	mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return model.NetworkEntry{
+		URL:             resp.Request.URL.String(),
+		Method:          resp.Request.Method,
+		ResourceType:    "Document",
+		Initiator:       model.Initiator{Type: "other"},
+		StartedAt:       h.last,
+		RequestHeaders:  httpHeaders(resp.Request.Header),
+		Status:          resp.StatusCode,
+		StatusText:      http.StatusText(resp.StatusCode),
+		Protocol:        protocol(resp),
+		MimeType:        mimeType,
+		ResponseHeaders: httpHeaders(resp.Header),
+		// Connection phases are not observed without a browser
+		Timings: model.Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: millis(respondedAt.Sub(h.last))},
+	}
+}
+
+func httpHeaders(h http.Header) []model.Header {
+	headers := make([]model.Header, 0, len(h))
+	for name, values := range h {
+		for _, v := range values {
+			headers = append(headers, model.Header{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+	return headers
+}
+
+// protocol names the HTTP version the way Chrome reports it
+func protocol(resp *http.Response) string {
+	if resp.ProtoMajor == 2 {
+		return "h2"
+	}
+	return strings.ToLower(resp.Proto)
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
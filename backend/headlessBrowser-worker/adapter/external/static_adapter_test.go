@@ -0,0 +1,117 @@
+package external
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+func TestStaticAdapter_FollowsRedirectsAndDecodes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Language") != "de-DE" {
+			t.Errorf("expected the emulated language, got %q", r.Header.Get("Accept-Language"))
+		}
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.Write([]byte("<title>Gr\xfc\xdfe</title>"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	opts := model.RenderOptions{Emulation: &model.Emulation{AcceptLanguage: "de-DE"}}
+	page, err := NewStaticAdapter(0).GetRenderedHTML(context.Background(), srv.URL+"/old", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page.HTML, "Grüße") {
+		t.Errorf("expected the document decoded from latin-1, got %q", page.HTML)
+	}
+	if page.Mode != model.RenderStatic || len(page.Network) != 2 {
+		t.Fatalf("expected a static page with two hops, got %s with %+v", page.Mode, page.Network)
+	}
+	if page.FinalURL != srv.URL+"/new" || page.Status != http.StatusOK || len(page.Redirects) != 1 || page.Redirects[0].Location != srv.URL+"/new" {
+		t.Errorf("unexpected redirect chain %s %d %+v", page.FinalURL, page.Status, page.Redirects)
+	}
+	if hop := page.Network[0]; hop.Status != http.StatusMovedPermanently || hop.RedirectURL != srv.URL+"/new" {
+		t.Errorf("unexpected redirect hop %+v", hop)
+	}
+	if final := page.Network[1]; final.Status != http.StatusOK || final.MimeType != "text/html" || final.ContentSize <= final.TransferSize {
+		t.Errorf("unexpected final entry %+v", final)
+	}
+}
+
+func TestStaticAdapter_EnforcesMaxBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	}))
+	defer srv.Close()
+
+	_, err := NewStaticAdapter(1024).GetRenderedHTML(context.Background(), srv.URL, model.RenderOptions{})
+	if !errors.Is(err, ErrDocumentTooLarge) {
+		t.Errorf("expected ErrDocumentTooLarge, got %v", err)
+	}
+}
+
+func TestStaticAdapter_CountsCompressedTransfer(t *testing.T) {
+	doc := "<html><body>" + strings.Repeat("<p>Compressible text.</p>", 200) + "</body></html>"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write([]byte(doc))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(doc))
+		gz.Close()
+	}))
+	defer srv.Close()
+
+	page, err := NewStaticAdapter(0).GetRenderedHTML(context.Background(), srv.URL, model.RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.HTML != doc {
+		t.Fatalf("expected the uncompressed document, got %d bytes", len(page.HTML))
+	}
+	final := page.Network[len(page.Network)-1]
+	if final.TransferSize <= 0 || final.TransferSize >= final.ContentSize/4 {
+		t.Errorf("expected the compressed size on the wire, got %d of %d", final.TransferSize, final.ContentSize)
+	}
+}
+
+func TestStaticAdapter_RejectsNonDocuments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.7"))
+		case "/untyped.png":
+			w.Header()["Content-Type"] = nil
+			w.Write([]byte("\x89PNG\r\n\x1a\n"))
+		case "/feed":
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte("<?xml version=\"1.0\"?><rss></rss>"))
+		}
+	}))
+	defer srv.Close()
+
+	for _, path := range []string{"/report.pdf", "/untyped.png"} {
+		if _, err := NewStaticAdapter(0).GetRenderedHTML(context.Background(), srv.URL+path, model.RenderOptions{}); !errors.Is(err, ErrNotHTML) {
+			t.Errorf("%s: expected ErrNotHTML, got %v", path, err)
+		}
+	}
+	if _, err := NewStaticAdapter(0).GetRenderedHTML(context.Background(), srv.URL+"/feed", model.RenderOptions{}); err != nil {
+		t.Errorf("expected an XML document to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package external
+
+import (
+	"context"
+	"headlessBrowser-worker/domain/model"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// vitalsObserverScript runs before any page script and buffers the entries
+// that are only observable through PerformanceObserver. CLS uses the
+// session windows of the web-vitals definition: shifts less than 1s apart,
+// at most 5s per window, largest window wins.
+const vitalsObserverScript = `(() => {
+  const v = window.__snappyVitals = { lcp: null, cls: 0, longTasks: [] };
+  let windowValue = 0, windowStart = 0, lastShift = 0;
+  const observe = (type, fn) => {
+    try { new PerformanceObserver(list => list.getEntries().forEach(fn)).observe({ type, buffered: true }); } catch (e) {}
+  };
+  observe('largest-contentful-paint', e => { v.lcp = e.startTime; });
+  observe('layout-shift', e => {
+    if (e.hadRecentInput) return;
+    if (windowValue && (e.startTime - lastShift > 1000 || e.startTime - windowStart > 5000)) {
+      windowValue = 0;
+    }
+    if (!windowValue) windowStart = e.startTime;
+    windowValue += e.value;
+    lastShift = e.startTime;
+    v.cls = Math.max(v.cls, windowValue);
+  });
+  observe('longtask', e => { v.longTasks.push([e.startTime, e.duration]); });
+})();`
+
+// collectVitalsScript reads navigation and paint timing and what the
+// observers saw so far. TBT counts the blocking part of long tasks after
+// FCP up to now, since there is no interactive point to stop at.
+const collectVitalsScript = `(() => {
+  const nav = performance.getEntriesByType('navigation')[0];
+  const fcpEntry = performance.getEntriesByName('first-contentful-paint')[0];
+  const v = window.__snappyVitals || { lcp: null, cls: null, longTasks: [] };
+  const fcp = fcpEntry ? fcpEntry.startTime : null;
+  let tbt = null;
+  if (fcp !== null) {
+    tbt = v.longTasks
+      .filter(([start]) => start >= fcp)
+      .reduce((sum, [, duration]) => sum + Math.max(0, duration - 50), 0);
+  }
+  const positive = x => (x > 0 ? x : null);
+  return {
+    ttfb: nav ? positive(nav.responseStart) : null,
+    dom_content_loaded: nav ? positive(nav.domContentLoadedEventStart) : null,
+    load: nav ? positive(nav.loadEventStart) : null,
+    fcp, lcp: v.lcp, cls: v.cls, tbt,
+  };
+})()`
+
+// installVitalsObservers must run before navigating
+func installVitalsObservers() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		_, err := page.AddScriptToEvaluateOnNewDocument(vitalsObserverScript).Do(ctx)
+		return err
+	})
+}
+
+func collectVitals(vitals *model.VitalsSample) chromedp.Action {
+	return chromedp.Evaluate(collectVitalsScript, vitals)
+}
@@ -0,0 +1,304 @@
+package messaging
+
+import (
+	"common/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"headlessBrowser-worker/application/analysis"
+	"headlessBrowser-worker/domain/model"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// JobsStream persists every request published on JobsSubject until a
+	// worker acks it
+	JobsStream = "ANALYZE_JOBS"
+	// JobsDurable is shared by all workers, so each job goes to one of them
+	JobsDurable = "headless-workers"
+	// DeadLetterSubject receives jobs that exceeded the delivery limit
+	DeadLetterSubject = "analyze.dead"
+	DeadLetterStream  = "ANALYZE_DEAD"
+)
+
+// JetStreamJobConsumer is the durable alternative to NATSJobConsumer. A job
+// is acked only after its terminal event was published; if the worker dies
+// first, JetStream redelivers it to another worker once AckWait expires.
+type JetStreamJobConsumer struct {
+	Queue *analysis.JobQueue
+	// Workers is how many jobs from JetStream this worker holds at once;
+	// it should match the workers of Queue
+	Workers int
+	// MaxDeliveries counts deliveries to a worker that took the job, so
+	// only crashes use it up, never a busy worker
+	MaxDeliveries int
+	// AckWait must be at least a second; the lease of a held job is
+	// renewed every AckWait/3
+	AckWait time.Duration
+	// RetryDelay is how long a job waits before redelivery when its result
+	// could not be published, and how often a full local queue is retried
+	RetryDelay time.Duration
+
+	js jetstream.JetStream
+}
+
+// minAckWait keeps the heartbeat of a held job well above zero
+const minAckWait = time.Second
+
+// Start declares the job and dead-letter streams and begins consuming;
+// stop it with the returned func
+func (c *JetStreamJobConsumer) Start(ctx context.Context, nc *nats.Conn) (stop func(), err error) {
+	if c.AckWait < minAckWait {
+		return nil, fmt.Errorf("ack wait %v is below %v", c.AckWait, minAckWait)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+	c.js = js
+
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      JobsStream,
+		Subjects:  []string{JobsSubject},
+		Retention: jetstream.WorkQueuePolicy,
+		Storage:   jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare stream %s: %w", JobsStream, err)
+	}
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     DeadLetterStream,
+		Subjects: []string{DeadLetterSubject},
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare stream %s: %w", DeadLetterStream, err)
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, JobsStream, jetstream.ConsumerConfig{
+		Durable:   JobsDurable,
+		AckPolicy: jetstream.AckExplicitPolicy,
+		AckWait:   c.AckWait,
+		// The delivery limit is enforced in handle so exhausted jobs can be
+		// dead-lettered instead of silently dropped
+		MaxDeliver: -1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer %s: %w", JobsDurable, err)
+	}
+	pullCtx, cancel := context.WithCancel(context.Background())
+	go c.pull(pullCtx, consumer)
+	return cancel, nil
+}
+
+// pull fetches one job at a time and only while fewer than Workers jobs are
+// held, so jobs this worker cannot start soon stay in the stream for others
+func (c *JetStreamJobConsumer) pull(ctx context.Context, consumer jetstream.Consumer) {
+	held := make(chan struct{}, max(c.Workers, 1))
+	for {
+		select {
+		case held <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		msg, err := consumer.Next(jetstream.FetchContext(ctx))
+		if err != nil {
+			<-held
+			if ctx.Err() != nil {
+				return
+			}
+			if !errors.Is(err, nats.ErrTimeout) && !errors.Is(err, context.DeadlineExceeded) {
+				slog.Warn("failed to fetch job from JetStream", "error", err)
+				sleep(ctx, c.RetryDelay)
+			}
+			continue
+		}
+		c.handle(ctx, msg, func() { <-held })
+	}
+}
+
+// handle starts the job of msg; release is called once the job no longer
+// holds one of the Workers
+func (c *JetStreamJobConsumer) handle(ctx context.Context, msg jetstream.Msg, release func()) {
+	submitted := false
+	defer func() {
+		if !submitted {
+			release()
+		}
+	}()
+
+	meta, err := msg.Metadata()
+	if err != nil {
+		slog.Error("job message without metadata", "error", err)
+		msg.Term()
+		return
+	}
+
+	var req JobRequest
+	err = json.Unmarshal(msg.Data(), &req)
+	if req.JobID != "" && !model.ValidJobID(req.JobID) {
+		// The ID would break the event subject and artifact paths, so its
+		// client cannot be told
+		c.deadLetter(msg, JobRequest{URL: req.URL}, meta, "invalid job_id")
+		return
+	}
+	if err != nil || req.URL == "" {
+		c.deadLetter(msg, req, meta, "invalid job request")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.deadLetter(msg, req, meta, err.Error())
+		return
+	}
+	// Redeliveries must keep the same ID so the client's subscription still matches
+	if req.JobID == "" {
+		req.JobID = "js-" + strconv.FormatUint(meta.Sequence.Stream, 10)
+	}
+	l := logger.Scoped("worker", "jetstream", req.JobID, msg.Subject(), "NATS").With("delivery", meta.NumDelivered)
+
+	existing, known := c.Queue.UseCase.Jobs.Get(req.JobID)
+	if known && existing.Status.IsTerminal() {
+		// The job ended here, e.g. it was cancelled, and was handed back only
+		// because its terminal event was lost; running it again would undo
+		// a cancel
+		c.settle(msg, meta, existing, l)
+		return
+	}
+	if meta.NumDelivered > uint64(c.MaxDeliveries) {
+		c.deadLetter(msg, req, meta, fmt.Sprintf("gave up after %d deliveries", c.MaxDeliveries))
+		return
+	}
+	if known {
+		// Still queued or running here; the original delivery will be acked
+		l.Warn("ignoring duplicate delivery of an active job")
+		return
+	}
+
+	job := model.NewJob(req.URL)
+	job.ID = req.JobID
+	job.Options = req.RenderOptions
+	stop := c.heartbeat(msg)
+	done := func(err error) {
+		stop()
+		release()
+		if err != nil {
+			l.Warn("result not published, job will be redelivered", "error", err)
+			msg.NakWithDelay(c.RetryDelay)
+			return
+		}
+		msg.Ack()
+	}
+	// A full queue is waited out here rather than handed back to JetStream,
+	// where it would count as a delivery
+	for {
+		_, err = c.Queue.Submit(job, l, done)
+		if errors.Is(err, analysis.ErrJobExists) {
+			// Another request took the ID since it was looked up
+			l.Warn("dropping job with a job_id already in use")
+			stop()
+			msg.Term()
+			return
+		}
+		if !errors.Is(err, analysis.ErrQueueFull) {
+			break
+		}
+		l.Info("queue full, holding job", "queue_depth", c.Queue.Depth())
+		if sleep(ctx, c.RetryDelay) != nil {
+			stop()
+			msg.Nak()
+			return
+		}
+	}
+	submitted = true
+	l.Info("job received from JetStream", "url", req.URL)
+}
+
+// settle acks a job that already ended on this worker once its terminal
+// event is published again; after MaxDeliveries attempts it is dropped
+func (c *JetStreamJobConsumer) settle(msg jetstream.Msg, meta *jetstream.MsgMetadata, job model.Job, l *slog.Logger) {
+	if err := c.Queue.UseCase.Republish(context.Background(), job); err != nil {
+		if meta.NumDelivered >= uint64(c.MaxDeliveries) {
+			l.Error("terminal event not published, dropping ended job", "status", job.Status, "error", err)
+			msg.Term()
+			return
+		}
+		l.Warn("terminal event not published, job will be redelivered", "status", job.Status, "error", err)
+		msg.NakWithDelay(c.RetryDelay)
+		return
+	}
+	l.Info("acked redelivery of an ended job", "status", job.Status)
+	msg.Ack()
+}
+
+// sleep waits for d unless ctx ends first
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// heartbeat keeps extending the ack deadline while the job waits or runs, so
+// only a dead worker lets it expire. The returned func stops it.
+func (c *JetStreamJobConsumer) heartbeat(msg jetstream.Msg) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(c.AckWait / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				msg.InProgress()
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// deadLetter moves a job that keeps failing (for example a URL that crashes
+// Chrome) to DeadLetterSubject and tells its client it failed
+func (c *JetStreamJobConsumer) deadLetter(msg jetstream.Msg, req JobRequest, meta *jetstream.MsgMetadata, reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	l := slog.With("job_id", req.JobID, "url", req.URL, "reason", reason)
+
+	dead := nats.NewMsg(DeadLetterSubject)
+	dead.Data = msg.Data()
+	dead.Header.Set("Job-Id", req.JobID)
+	dead.Header.Set("Reason", reason)
+	dead.Header.Set("Deliveries", strconv.FormatUint(meta.NumDelivered, 10))
+	dead.Header.Set("Stream-Sequence", strconv.FormatUint(meta.Sequence.Stream, 10))
+	if _, err := c.js.PublishMsg(ctx, dead); err != nil {
+		// Leave the job unacked so it is not lost
+		l.Error("failed to dead-letter job", "error", err)
+		msg.NakWithDelay(c.RetryDelay)
+		return
+	}
+	l.Warn("job dead-lettered")
+
+	if req.JobID != "" {
+		now := time.Now()
+		detail := &model.ErrorDetail{StatusCode: 500, Message: "The page could not be analyzed: " + reason}
+		c.Queue.UseCase.Jobs.Create(&model.Job{
+			ID: req.JobID, URL: req.URL, Status: model.JobFailed,
+			CreatedAt: now, FinishedAt: &now, Error: detail,
+		})
+		result := model.AnalysisResult{JobID: req.JobID, URL: req.URL, Error: detail}
+		if err := c.Queue.UseCase.Publisher.Publish(ctx, model.Event{JobID: req.JobID, Type: model.EventFailed, Data: result}); err != nil {
+			l.Error("failed to publish dead-letter state", "error", err)
+		}
+	}
+	msg.Term()
+}
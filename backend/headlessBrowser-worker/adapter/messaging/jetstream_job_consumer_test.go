@@ -0,0 +1,242 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"headlessBrowser-worker/adapter/repository"
+	"headlessBrowser-worker/application/analysis"
+	"headlessBrowser-worker/domain/model"
+	"headlessBrowser-worker/domain/service"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type staticBrowser struct{}
+
+func (staticBrowser) GetRenderedHTML(ctx context.Context, url string, opts model.RenderOptions) (*model.RenderedPage, error) {
+	return &model.RenderedPage{HTML: "<html><head><title>Hi</title></head></html>"}, nil
+}
+
+type memoryPublisher struct {
+	mu     sync.Mutex
+	events []model.Event
+}
+
+func (p *memoryPublisher) Publish(ctx context.Context, event model.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func startJetStreamConsumer(t *testing.T, nc *nats.Conn, queue *analysis.JobQueue, maxDeliveries int) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	consumer := &JetStreamJobConsumer{Queue: queue, Workers: 1, MaxDeliveries: maxDeliveries, AckWait: time.Second, RetryDelay: 10 * time.Millisecond}
+	stop, err := consumer.Start(ctx, nc)
+	if err != nil {
+		t.Fatalf("failed to start consumer: %v", err)
+	}
+	t.Cleanup(stop)
+}
+
+// hangingBrowser never finishes a render and reports each one it starts
+type hangingBrowser struct{ started chan string }
+
+func (b hangingBrowser) GetRenderedHTML(ctx context.Context, url string, opts model.RenderOptions) (*model.RenderedPage, error) {
+	b.started <- url
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// waitFor polls cond until it holds or the deadline passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestJetStreamJobConsumer_AcksAfterResult(t *testing.T) {
+	nc := runServer(t)
+	jobs := repository.NewMemoryJobRepository(time.Hour)
+	publisher := &memoryPublisher{}
+	queue := analysis.NewJobQueue(&analysis.AnalyzeURLUseCase{Browser: staticBrowser{}, Checker: service.NewLinkChecker(service.LinkCheckerConfig{}), Publisher: publisher, Jobs: jobs}, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue.Start(ctx, 1)
+	startJetStreamConsumer(t, nc, queue, 3)
+
+	js, _ := jetstream.New(nc)
+	if _, err := js.Publish(ctx, JobsSubject, []byte(`{"job_id":"job-1","url":"https://example.com"}`)); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	waitFor(t, "job to finish", func() bool {
+		job, ok := jobs.Get("job-1")
+		return ok && job.Status == model.JobDone
+	})
+	stream, _ := js.Stream(ctx, JobsStream)
+	waitFor(t, "job to be acked", func() bool {
+		info, err := stream.Info(ctx)
+		return err == nil && info.State.Msgs == 0
+	})
+}
+
+func TestJetStreamJobConsumer_DeadLettersAfterMaxDeliveries(t *testing.T) {
+	nc := runServer(t)
+	ctx := context.Background()
+	js, _ := jetstream.New(nc)
+
+	// Each worker starts the job and dies before acking it, so JetStream
+	// redelivers it once AckWait expires
+	for i := 0; i < 2; i++ {
+		crashing, err := nats.Connect(nc.ConnectedUrl())
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		browser := hangingBrowser{started: make(chan string, 1)}
+		queue := analysis.NewJobQueue(&analysis.AnalyzeURLUseCase{Browser: browser, Publisher: &memoryPublisher{}, Jobs: repository.NewMemoryJobRepository(time.Hour)}, 5)
+		workerCtx, stopWorker := context.WithCancel(ctx)
+		queue.Start(workerCtx, 1)
+		startJetStreamConsumer(t, crashing, queue, 2)
+		if i == 0 {
+			if _, err := js.Publish(ctx, JobsSubject, []byte(`{"job_id":"job-1","url":"https://crash.example"}`)); err != nil {
+				t.Fatalf("publish failed: %v", err)
+			}
+		}
+		select {
+		case <-browser.started:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("delivery %d was not started", i+1)
+		}
+		crashing.Close()
+		stopWorker()
+	}
+
+	jobs := repository.NewMemoryJobRepository(time.Hour)
+	publisher := &memoryPublisher{}
+	queue := analysis.NewJobQueue(&analysis.AnalyzeURLUseCase{Browser: staticBrowser{}, Checker: service.NewLinkChecker(service.LinkCheckerConfig{}), Publisher: publisher, Jobs: jobs}, 5)
+	startJetStreamConsumer(t, nc, queue, 2)
+
+	dead, _ := js.Stream(ctx, DeadLetterStream)
+	waitFor(t, "job to be dead-lettered", func() bool {
+		info, err := dead.Info(ctx)
+		return err == nil && info.State.Msgs == 1
+	})
+	msg, err := dead.GetLastMsgForSubject(ctx, DeadLetterSubject)
+	if err != nil {
+		t.Fatalf("failed to read dead letter: %v", err)
+	}
+	if msg.Header.Get("Job-Id") != "job-1" || msg.Header.Get("Deliveries") != "3" {
+		t.Errorf("unexpected dead-letter headers %v", msg.Header)
+	}
+	if job, ok := jobs.Get("job-1"); !ok || job.Status != model.JobFailed {
+		t.Errorf("expected job-1 to be failed, got %+v (found=%v)", job, ok)
+	}
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	if len(publisher.events) != 1 || publisher.events[0].Type != model.EventFailed {
+		t.Errorf("expected a single failed event, got %v", publisher.events)
+	}
+}
+
+func TestJetStreamJobConsumer_FullQueueDoesNotUseDeliveries(t *testing.T) {
+	nc := runServer(t)
+	jobs := repository.NewMemoryJobRepository(time.Hour)
+	publisher := &memoryPublisher{}
+	queue := analysis.NewJobQueue(&analysis.AnalyzeURLUseCase{Browser: staticBrowser{}, Checker: service.NewLinkChecker(service.LinkCheckerConfig{}), Publisher: publisher, Jobs: jobs}, 1)
+	// Fill the queue before any worker runs
+	if _, err := queue.Submit(model.NewJob("https://busy.example"), slog.Default(), nil); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	startJetStreamConsumer(t, nc, queue, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	js, _ := jetstream.New(nc)
+	if _, err := js.Publish(ctx, JobsSubject, []byte(`{"job_id":"job-1","url":"https://example.com"}`)); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	// Far longer than RetryDelay, so a nak per attempt would exhaust the limit
+	time.Sleep(300 * time.Millisecond)
+	queue.Start(ctx, 1)
+
+	waitFor(t, "job to finish", func() bool {
+		job, ok := jobs.Get("job-1")
+		return ok && job.Status == model.JobDone
+	})
+	dead, _ := js.Stream(ctx, DeadLetterStream)
+	if info, err := dead.Info(ctx); err != nil || info.State.Msgs != 0 {
+		t.Errorf("expected no dead letters, got %+v (err=%v)", info, err)
+	}
+}
+
+// lossyPublisher fails the first cancelled event it is given
+type lossyPublisher struct {
+	memoryPublisher
+	lost bool
+}
+
+func (p *lossyPublisher) Publish(ctx context.Context, event model.Event) error {
+	p.mu.Lock()
+	if event.Type == model.EventCancelled && !p.lost {
+		p.lost = true
+		p.mu.Unlock()
+		return errors.New("socket server unavailable")
+	}
+	p.mu.Unlock()
+	return p.memoryPublisher.Publish(ctx, event)
+}
+
+func TestJetStreamJobConsumer_CancelledJobIsNotRunAgain(t *testing.T) {
+	nc := runServer(t)
+	jobs := repository.NewMemoryJobRepository(time.Hour)
+	publisher := &lossyPublisher{}
+	browser := hangingBrowser{started: make(chan string, 2)}
+	queue := analysis.NewJobQueue(&analysis.AnalyzeURLUseCase{Browser: browser, Checker: service.NewLinkChecker(service.LinkCheckerConfig{}), Publisher: publisher, Jobs: jobs}, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue.Start(ctx, 1)
+	startJetStreamConsumer(t, nc, queue, 3)
+
+	js, _ := jetstream.New(nc)
+	if _, err := js.Publish(ctx, JobsSubject, []byte(`{"job_id":"job-1","url":"https://example.com"}`)); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	<-browser.started
+	// The cancelled event is lost, so the job is handed back to JetStream
+	if err := queue.Cancel("job-1"); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+
+	stream, _ := js.Stream(ctx, JobsStream)
+	waitFor(t, "redelivery to be acked", func() bool {
+		info, err := stream.Info(ctx)
+		return err == nil && info.State.Msgs == 0
+	})
+	select {
+	case <-browser.started:
+		t.Fatal("cancelled job was rendered again")
+	default:
+	}
+	if job, _ := jobs.Get("job-1"); job.Status != model.JobCancelled {
+		t.Errorf("expected the job to stay cancelled, got %s", job.Status)
+	}
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	if n := len(publisher.events); n == 0 || publisher.events[n-1].Type != model.EventCancelled {
+		t.Errorf("expected the cancelled event to be published again, got %v", publisher.events)
+	}
+}
@@ -0,0 +1,95 @@
+package messaging
+
+import (
+	"common/logger"
+	"encoding/json"
+	"errors"
+	"headlessBrowser-worker/application/analysis"
+	"headlessBrowser-worker/domain/model"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// JobsSubject receives analysis requests from any producer
+	JobsSubject = "analyze.requests"
+	// JobsQueueGroup makes NATS hand each request to exactly one worker
+	JobsQueueGroup = "headless-workers"
+)
+
+// JobRequest is the payload published on JobsSubject. JobID is optional; a
+// producer sets it to subscribe to the job's events before submitting.
+type JobRequest struct {
+	JobID string `json:"job_id,omitempty"`
+	URL   string `json:"url"`
+	// Render options use the same fields as the POST /analyze body
+	model.RenderOptions
+}
+
+// JobReply answers producers that publish with a reply subject
+type JobReply struct {
+	JobID         string `json:"job_id,omitempty"`
+	Status        string `json:"status"`
+	QueuePosition int    `json:"queue_position,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// NATSJobConsumer feeds analysis requests from a NATS queue group into the
+// same JobQueue used by POST /analyze
+type NATSJobConsumer struct {
+	Queue *analysis.JobQueue
+}
+
+func (c *NATSJobConsumer) Start(nc *nats.Conn) (*nats.Subscription, error) {
+	return nc.QueueSubscribe(JobsSubject, JobsQueueGroup, c.handle)
+}
+
+func (c *NATSJobConsumer) handle(msg *nats.Msg) {
+	var req JobRequest
+	if err := json.Unmarshal(msg.Data, &req); err != nil || req.URL == "" {
+		slog.Warn("dropping invalid job request", "subject", msg.Subject, "error", err)
+		c.reply(msg, JobReply{Status: "rejected", Error: "invalid job request"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.reply(msg, JobReply{JobID: req.JobID, Status: "rejected", Error: err.Error()})
+		return
+	}
+
+	job := model.NewJob(req.URL)
+	job.Options = req.RenderOptions
+	if req.JobID != "" {
+		// The ID becomes part of the event subject and artifact paths
+		if !model.ValidJobID(req.JobID) {
+			c.reply(msg, JobReply{Status: "rejected", Error: "invalid job_id"})
+			return
+		}
+		job.ID = req.JobID
+	}
+	l := logger.Scoped("worker", "nats", job.ID, msg.Subject, "NATS")
+
+	position, err := c.Queue.Submit(job, l, nil)
+	if errors.Is(err, analysis.ErrJobExists) {
+		c.reply(msg, JobReply{JobID: job.ID, Status: "rejected", Error: "duplicate job_id"})
+		return
+	}
+	if errors.Is(err, analysis.ErrQueueFull) {
+		l.Warn("queue full, rejecting job", "queue_depth", c.Queue.Depth())
+		c.reply(msg, JobReply{JobID: job.ID, Status: "rejected", Error: err.Error()})
+		return
+	}
+	l.Info("job received from NATS", "url", req.URL)
+	c.reply(msg, JobReply{JobID: job.ID, Status: "processing", QueuePosition: position})
+}
+
+// reply is a no-op for fire-and-forget publishes
+func (c *NATSJobConsumer) reply(msg *nats.Msg, reply JobReply) {
+	if msg.Reply == "" {
+		return
+	}
+	data, _ := json.Marshal(reply)
+	if err := msg.Respond(data); err != nil {
+		slog.Error("failed to reply to job request", "error", err)
+	}
+}
@@ -0,0 +1,60 @@
+package messaging
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"headlessBrowser-worker/adapter/repository"
+	"headlessBrowser-worker/application/analysis"
+	"headlessBrowser-worker/domain/model"
+)
+
+func TestNATSJobConsumer_QueuesRequest(t *testing.T) {
+	nc := runServer(t)
+	jobs := repository.NewMemoryJobRepository(time.Hour)
+	// No workers are started, so submitted jobs stay queued
+	queue := analysis.NewJobQueue(&analysis.AnalyzeURLUseCase{Jobs: jobs}, 1)
+
+	sub, err := (&NATSJobConsumer{Queue: queue}).Start(nc)
+	if err != nil {
+		t.Fatalf("failed to start consumer: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	request := func(payload string) JobReply {
+		t.Helper()
+		msg, err := nc.Request(JobsSubject, []byte(payload), 2*time.Second)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		var reply JobReply
+		if err := json.Unmarshal(msg.Data, &reply); err != nil {
+			t.Fatalf("invalid reply: %v", err)
+		}
+		return reply
+	}
+
+	reply := request(`{"job_id":"job-1","url":"https://example.com"}`)
+	if reply.Status != "processing" || reply.JobID != "job-1" || reply.QueuePosition != 1 {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+	if job, ok := jobs.Get("job-1"); !ok || job.Status != model.JobQueued {
+		t.Errorf("expected job-1 to be queued, got %+v (found=%v)", job, ok)
+	}
+
+	if reply := request(`{"url":"https://example.org"}`); reply.Status != "rejected" {
+		t.Errorf("expected full queue to reject, got %+v", reply)
+	}
+	if reply := request(`{"job_id":"job-1","url":"https://example.com"}`); reply.Error != "duplicate job_id" {
+		t.Errorf("expected duplicate job_id to be rejected, got %+v", reply)
+	}
+	for _, id := range []string{"job.>", "../../tmp/x", "*"} {
+		if reply := request(`{"job_id":"` + id + `","url":"https://example.net"}`); reply.Error != "invalid job_id" {
+			t.Errorf("expected job_id %q to be rejected, got %+v", id, reply)
+		}
+	}
+	if reply := request(`not json`); reply.Status != "rejected" {
+		t.Errorf("expected invalid request to be rejected, got %+v", reply)
+	}
+}
@@ -0,0 +1,57 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"headlessBrowser-worker/domain/model"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// EventsStream keeps job events for eventsMaxAge, so a socket server
+	// can replay them to a client that subscribes late. The worker owns
+	// it; the socket server only reads from it.
+	EventsStream = "ANALYSIS_EVENTS"
+	// EventsSubjectPrefix is followed by the job ID, e.g. analysis.events.<id>
+	EventsSubjectPrefix = "analysis.events"
+	eventsMaxAge        = 15 * time.Minute
+)
+
+// NATSPublisher publishes job events to a JetStream stream instead of
+// posting them to the socket server over HTTP
+type NATSPublisher struct {
+	js jetstream.JetStream
+}
+
+// NewNATSPublisher makes sure the events stream exists
+func NewNATSPublisher(ctx context.Context, nc *nats.Conn) (*NATSPublisher, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     EventsStream,
+		Subjects: []string{EventsSubjectPrefix + ".>"},
+		MaxAge:   eventsMaxAge,
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare stream %s: %w", EventsStream, err)
+	}
+	return &NATSPublisher{js: js}, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if _, err := p.js.Publish(ctx, EventsSubjectPrefix+"."+event.JobID, data); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"headlessBrowser-worker/domain/model"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// runServer starts an embedded nats-server with JetStream on a random port
+func runServer(t *testing.T) *nats.Conn {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to create nats-server: %v", err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats-server not ready")
+	}
+	t.Cleanup(s.Shutdown)
+
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+func TestNATSPublisher_PublishesToJobSubject(t *testing.T) {
+	nc := runServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	publisher, err := NewNATSPublisher(ctx, nc)
+	if err != nil {
+		t.Fatalf("failed to create publisher: %v", err)
+	}
+	event := model.Event{JobID: "job-1", Type: model.EventLinkChecked, Data: model.LinkProgress{Checked: 1, Total: 2}}
+	if err := publisher.Publish(ctx, event); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	js, _ := jetstream.New(nc)
+	stream, err := js.Stream(ctx, EventsStream)
+	if err != nil {
+		t.Fatalf("stream not declared: %v", err)
+	}
+	msg, err := stream.GetLastMsgForSubject(ctx, EventsSubjectPrefix+".job-1")
+	if err != nil {
+		t.Fatalf("event not stored in stream: %v", err)
+	}
+	var got struct {
+		JobID string             `json:"job_id"`
+		Type  model.EventType    `json:"type"`
+		Data  model.LinkProgress `json:"data"`
+	}
+	if err := json.Unmarshal(msg.Data, &got); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if got.JobID != "job-1" || got.Type != model.EventLinkChecked || got.Data.Total != 2 {
+		t.Errorf("unexpected event %+v", got)
+	}
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"headlessBrowser-worker/application/core"
+	"headlessBrowser-worker/domain/model"
+)
+
+// LocalArtifactStore writes artifacts to <dir>/<job id>/<name> and serves them
+// under <base url>/artifacts/<job id>/<name>. Directories of jobs older than the
+// retention period are removed as new artifacts are saved; a retention of 0
+// keeps them.
+type LocalArtifactStore struct {
+	dir       string
+	baseURL   string
+	retention time.Duration
+}
+
+func NewLocalArtifactStore(dir, baseURL string, retention time.Duration) (*LocalArtifactStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalArtifactStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), retention: retention}, nil
+}
+
+func (s *LocalArtifactStore) Save(jobID, name string, data []byte) (model.Artifact, error) {
+	path, err := s.path(jobID, name)
+	if err != nil {
+		return model.Artifact{}, err
+	}
+	s.prune(time.Now())
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return model.Artifact{}, err
+	}
+	// Write to a temporary file first so readers never see a partial artifact
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return model.Artifact{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return model.Artifact{}, err
+	}
+	return s.artifact(jobID, name, int64(len(data)), time.Now()), nil
+}
+
+func (s *LocalArtifactStore) Open(jobID, name string) (io.ReadSeekCloser, model.Artifact, error) {
+	path, err := s.path(jobID, name)
+	if err != nil {
+		return nil, model.Artifact{}, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, model.Artifact{}, core.ErrArtifactNotFound
+	}
+	if err != nil {
+		return nil, model.Artifact{}, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, model.Artifact{}, err
+	}
+	return f, s.artifact(jobID, name, info.Size(), info.ModTime()), nil
+}
+
+// path maps an artifact to its file, refusing anything that could escape
+// the store directory
+func (s *LocalArtifactStore) path(jobID, name string) (string, error) {
+	for _, part := range []string{jobID, name} {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `/\`) || strings.HasSuffix(part, ".tmp") {
+			return "", core.ErrArtifactNotFound
+		}
+	}
+	return filepath.Join(s.dir, jobID, name), nil
+}
+
+func (s *LocalArtifactStore) artifact(jobID, name string, size int64, created time.Time) model.Artifact {
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if filepath.Ext(name) == ".har" {
+		// HAR has no registered type; it is plain JSON
+		contentType = "application/json"
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	artifact := model.Artifact{
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+		URL:         s.baseURL + "/artifacts/" + url.PathEscape(jobID) + "/" + url.PathEscape(name),
+		CreatedAt:   created,
+	}
+	if s.retention > 0 {
+		expires := created.Add(s.retention)
+		artifact.ExpiresAt = &expires
+	}
+	return artifact
+}
+
+// prune removes job directories past retention
+func (s *LocalArtifactStore) prune(now time.Time) {
+	if s.retention <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil && entry.IsDir() && now.Sub(info.ModTime()) > s.retention {
+			os.RemoveAll(filepath.Join(s.dir, entry.Name()))
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"headlessBrowser-worker/application/core"
+)
+
+func TestLocalArtifactStore_SaveAndOpen(t *testing.T) {
+	store, err := NewLocalArtifactStore(t.TempDir(), "http://worker:8080/", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := store.Save("job1", "screenshot.png", []byte("png-bytes"))
+	if err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if saved.URL != "http://worker:8080/artifacts/job1/screenshot.png" || saved.ContentType != "image/png" || saved.Size != 9 {
+		t.Errorf("unexpected artifact %+v", saved)
+	}
+
+	content, opened, err := store.Open("job1", "screenshot.png")
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer content.Close()
+	data, _ := io.ReadAll(content)
+	if string(data) != "png-bytes" || opened.URL != saved.URL {
+		t.Errorf("unexpected content %q for %+v", data, opened)
+	}
+	if saved.ExpiresAt == nil || !saved.ExpiresAt.Equal(saved.CreatedAt.Add(time.Hour)) {
+		t.Errorf("expected the artifact to expire after the retention, got %v", saved.ExpiresAt)
+	}
+}
+
+func TestLocalArtifactStore_KeepsArtifactsWithoutRetention(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalArtifactStore(dir, "http://worker:8080", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := store.Save("old", "page.pdf", []byte("%PDF-1.7"))
+	if err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if saved.ExpiresAt != nil {
+		t.Errorf("expected no expiry, got %v", saved.ExpiresAt)
+	}
+	past := time.Now().Add(-365 * 24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "old"), past, past); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Save("new", "page.pdf", []byte("%PDF-1.7")); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	content, _, err := store.Open("old", "page.pdf")
+	if err != nil {
+		t.Fatalf("expected the old PDF to be kept, got %v", err)
+	}
+	content.Close()
+}
+
+func TestLocalArtifactStore_RejectsUnknownAndEscapingPaths(t *testing.T) {
+	store, err := NewLocalArtifactStore(t.TempDir(), "http://worker:8080", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range [][2]string{{"job1", "missing.png"}, {"..", "secret"}, {"job1", "../../etc/passwd"}, {"job1", ""}} {
+		if _, _, err := store.Open(path[0], path[1]); !errors.Is(err, core.ErrArtifactNotFound) {
+			t.Errorf("Open(%q, %q): expected ErrArtifactNotFound, got %v", path[0], path[1], err)
+		}
+	}
+	if _, err := store.Save("job1", "../escape.png", nil); err == nil {
+		t.Error("expected save outside the job directory to fail")
+	}
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+// MemoryJobRepository keeps jobs in process memory. Finished jobs are dropped
+// once they are older than the retention period.
+type MemoryJobRepository struct {
+	mu        sync.RWMutex
+	jobs      map[string]*model.Job
+	retention time.Duration
+}
+
+func NewMemoryJobRepository(retention time.Duration) *MemoryJobRepository {
+	return &MemoryJobRepository{
+		jobs:      make(map[string]*model.Job),
+		retention: retention,
+	}
+}
+
+func (r *MemoryJobRepository) Create(job *model.Job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked(time.Now())
+	stored := *job
+	r.jobs[job.ID] = &stored
+}
+
+func (r *MemoryJobRepository) Get(id string) (model.Job, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return model.Job{}, false
+	}
+	return *job, true
+}
+
+// List returns all jobs, newest first
+func (r *MemoryJobRepository) List() []model.Job {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	jobs := make([]model.Job, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+	return jobs
+}
+
+func (r *MemoryJobRepository) Update(id string, fn func(job *model.Job)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return false
+	}
+	fn(job)
+	return true
+}
+
+// pruneLocked removes finished jobs past retention; callers hold r.mu
+func (r *MemoryJobRepository) pruneLocked(now time.Time) {
+	if r.retention <= 0 {
+		return
+	}
+	for id, job := range r.jobs {
+		if job.Status.IsTerminal() && job.FinishedAt != nil && now.Sub(*job.FinishedAt) > r.retention {
+			delete(r.jobs, id)
+		}
+	}
+}
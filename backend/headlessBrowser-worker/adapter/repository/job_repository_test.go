@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+func TestMemoryJobRepository_UpdateAndGet(t *testing.T) {
+	repo := NewMemoryJobRepository(time.Hour)
+	job := model.NewJob("https://example.com")
+	repo.Create(job)
+
+	if ok := repo.Update(job.ID, func(j *model.Job) { j.Status = model.JobRendering }); !ok {
+		t.Fatal("expected update of known job to succeed")
+	}
+	got, ok := repo.Get(job.ID)
+	if !ok || got.Status != model.JobRendering {
+		t.Errorf("expected status %q, got %q (found=%v)", model.JobRendering, got.Status, ok)
+	}
+	if repo.Update("missing", func(j *model.Job) {}) {
+		t.Error("expected update of unknown job to fail")
+	}
+}
+
+func TestMemoryJobRepository_PrunesFinishedJobs(t *testing.T) {
+	repo := NewMemoryJobRepository(time.Minute)
+
+	old := model.NewJob("https://old.example.com")
+	repo.Create(old)
+	finished := time.Now().Add(-2 * time.Minute)
+	repo.Update(old.ID, func(j *model.Job) {
+		j.Status = model.JobDone
+		j.FinishedAt = &finished
+	})
+
+	running := model.NewJob("https://running.example.com")
+	repo.Create(running)
+
+	if _, ok := repo.Get(old.ID); ok {
+		t.Error("expected finished job past retention to be pruned")
+	}
+	if _, ok := repo.Get(running.ID); !ok {
+		t.Error("expected running job to be kept")
+	}
+}
@@ -3,31 +3,75 @@ package handle
 import (
 	"common/logger"
 	"encoding/json"
+	"errors"
 	"headlessBrowser-worker/application/analysis"
+	"headlessBrowser-worker/domain/model"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 type AnalysisHandler struct {
-	UseCase *analysis.AnalyzeURLUseCase
+	Queue *analysis.JobQueue
+	// RetryAfter is suggested to clients when the queue is full
+	RetryAfter time.Duration
 }
 
 func (h *AnalysisHandler) HandleAnalyze(w http.ResponseWriter, r *http.Request) {
-	l := logger.Scoped("worker", "system", "req_id", r.URL.Path, r.Method)
 	var req struct {
 		URL string `json:"url"`
+		// JobID lets the client subscribe to the job before submitting it,
+		// so no event is published before anyone listens
+		JobID string `json:"job_id"`
+		model.RenderOptions
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Bad Request", 400)
 		return
 	}
+	if !urlIsValid(req.URL) {
+		http.Error(w, "url must be an absolute http or https URL", http.StatusBadRequest)
+		return
+	}
+	if err := req.RenderOptions.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job := model.NewJob(req.URL)
+	if req.JobID != "" {
+		if !model.ValidJobID(req.JobID) {
+			http.Error(w, "job_id must be 1 to 64 letters, digits, '-' or '_'", http.StatusBadRequest)
+			return
+		}
+		job.ID = req.JobID
+	}
+	job.Options = req.RenderOptions
+	l := logger.Scoped("worker", "system", job.ID, r.URL.Path, r.Method)
 
-	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(map[string]string{"status": "processing"})
+	position, err := h.Queue.Submit(job, l, nil)
+	if errors.Is(err, analysis.ErrJobExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, analysis.ErrQueueFull) {
+		l.Warn("queue full, rejecting job", "queue_depth", h.Queue.Depth())
+		w.Header().Set("Retry-After", strconv.Itoa(int(h.RetryAfter.Seconds())))
+		http.Error(w, "Too many analyses in progress, try again later", http.StatusTooManyRequests)
+		return
+	}
 
-	go h.UseCase.Execute(req.URL, l)
+	// The client subscribes to job_id on the socket server to receive its result
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status":         "processing",
+		"job_id":         job.ID,
+		"queue_position": position,
+	})
 }
 
-func urlIsValid(url string) bool {
-	// Basic validation logic (can be expanded as needed)
-	return len(url) > 0
+// urlIsValid accepts the absolute http and https URLs a browser can load
+func urlIsValid(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
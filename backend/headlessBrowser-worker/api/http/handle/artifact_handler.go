@@ -0,0 +1,35 @@
+package handle
+
+import (
+	"errors"
+	"headlessBrowser-worker/application/core"
+	"log/slog"
+	"net/http"
+)
+
+type ArtifactHandler struct {
+	Store core.ArtifactStore
+	// PDFs, if set, serves the PDF exports kept apart from Store
+	PDFs core.ArtifactStore
+}
+
+// HandleGetArtifact serves GET /artifacts/{job}/{name}
+func (h *ArtifactHandler) HandleGetArtifact(w http.ResponseWriter, r *http.Request) {
+	content, artifact, err := h.Store.Open(r.PathValue("job"), r.PathValue("name"))
+	if errors.Is(err, core.ErrArtifactNotFound) && h.PDFs != nil {
+		content, artifact, err = h.PDFs.Open(r.PathValue("job"), r.PathValue("name"))
+	}
+	if errors.Is(err, core.ErrArtifactNotFound) {
+		http.Error(w, "Artifact not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to open artifact", "error", err, "path", r.URL.Path)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", artifact.ContentType)
+	http.ServeContent(w, r, artifact.Name, artifact.CreatedAt, content)
+}
@@ -0,0 +1,131 @@
+package handle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"headlessBrowser-worker/application/analysis"
+	"headlessBrowser-worker/application/core"
+	"headlessBrowser-worker/domain/model"
+	"net/http"
+	"strconv"
+)
+
+type JobHandler struct {
+	Jobs  core.JobRepository
+	Queue *analysis.JobQueue
+}
+
+// HandleGetJob serves GET /jobs/{id}
+func (h *JobHandler) HandleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.Jobs.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.withQueueInfo(job))
+}
+
+// HandleListJobs serves GET /jobs, optionally filtered with ?status=
+func (h *JobHandler) HandleListJobs(w http.ResponseWriter, r *http.Request) {
+	status := model.JobStatus(r.URL.Query().Get("status"))
+
+	jobs := []model.Job{}
+	for _, job := range h.Jobs.List() {
+		if status == "" || job.Status == status {
+			jobs = append(jobs, h.withQueueInfo(job))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"jobs": jobs, "count": len(jobs), "queue_depth": h.Queue.Depth()})
+}
+
+// maxLinkPageSize caps ?limit= of HandleGetJobLinks
+const maxLinkPageSize = 1000
+
+// HandleGetJobLinks serves GET /jobs/{id}/links, the checked links of a
+// finished job page by page with ?offset= and ?limit=. ?filter= narrows them
+// to inaccessible, skipped, internal or external links.
+func (h *JobHandler) HandleGetJobLinks(w http.ResponseWriter, r *http.Request) {
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		http.Error(w, "offset must be a non-negative number", http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt(r, "limit", model.LinkPageSize)
+	if err != nil || limit < 1 || limit > maxLinkPageSize {
+		http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxLinkPageSize), http.StatusBadRequest)
+		return
+	}
+	keep, ok := linkFilters[r.URL.Query().Get("filter")]
+	if !ok {
+		http.Error(w, "filter must be inaccessible, skipped, internal or external", http.StatusBadRequest)
+		return
+	}
+
+	job, ok := h.Jobs.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if job.Result == nil {
+		http.Error(w, "Job has no checked links", http.StatusConflict)
+		return
+	}
+
+	links := []model.LinkInfo{}
+	for _, link := range job.Links {
+		if keep(link) {
+			links = append(links, link)
+		}
+	}
+	writeJSON(w, http.StatusOK, model.PageLinks(links, offset, limit))
+}
+
+// linkFilters are the values of ?filter= on GET /jobs/{id}/links
+var linkFilters = map[string]func(model.LinkInfo) bool{
+	"":             func(model.LinkInfo) bool { return true },
+	"inaccessible": func(l model.LinkInfo) bool { return !l.Accessible && l.Skipped == "" },
+	"skipped":      func(l model.LinkInfo) bool { return l.Skipped != "" },
+	"internal":     func(l model.LinkInfo) bool { return !l.IsExternal },
+	"external":     func(l model.LinkInfo) bool { return l.IsExternal },
+}
+
+// HandleCancelJob serves DELETE /jobs/{id}
+func (h *JobHandler) HandleCancelJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := h.Queue.Cancel(id)
+	switch {
+	case errors.Is(err, analysis.ErrJobNotFound):
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	case errors.Is(err, analysis.ErrJobFinished):
+		http.Error(w, "Job already finished", http.StatusConflict)
+		return
+	}
+
+	job, _ := h.Jobs.Get(id)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// withQueueInfo adds the queue position of a job that is still waiting
+func (h *JobHandler) withQueueInfo(job model.Job) model.Job {
+	if job.Status == model.JobQueued {
+		job.QueuePosition, job.QueueDepth = h.Queue.Position(job.ID)
+	}
+	return job
+}
+
+// queryInt reads the integer query parameter key, or fallback if it is absent
+func queryInt(r *http.Request, key string, fallback int) (int, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
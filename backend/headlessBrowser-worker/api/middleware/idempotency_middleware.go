@@ -12,9 +12,11 @@ var (
 )
 
 // add code line for health check by passing through the request if the path is /health
+// Read-only and naturally idempotent requests (GET /jobs, DELETE /jobs/{id})
+// are safe to repeat and pass through as well
 func IdempotencyMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/health" {
+		if r.URL.Path == "/health" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodDelete {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -26,15 +28,35 @@ func IdempotencyMiddleware(next http.Handler) http.Handler {
 		}
 
 		muID.Lock()
-		defer muID.Unlock()
-
 		if _, exists := idempotencyKeys[idempotencyKey]; exists {
+			muID.Unlock()
 			http.Error(w, "Duplicate request", http.StatusConflict)
 			return
 		}
-
+		// Reserved while the request runs, so a concurrent duplicate is refused
 		idempotencyKeys[idempotencyKey] = time.Now()
+		muID.Unlock()
 
-		next.ServeHTTP(w, r)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// Only a request that was accepted is kept; after a rejection such
+		// as 429 "queue full" the client may retry with the same key
+		if rec.status < 200 || rec.status > 299 {
+			muID.Lock()
+			delete(idempotencyKeys, idempotencyKey)
+			muID.Unlock()
+		}
 	})
 }
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
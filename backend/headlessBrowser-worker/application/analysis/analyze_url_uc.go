@@ -2,55 +2,481 @@ package analysis
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"headlessBrowser-worker/application/core"
 	"headlessBrowser-worker/domain/model"
 	"headlessBrowser-worker/domain/service"
 	"log/slog"
+	"sync"
+	"time"
+)
+
+// linkProgressInterval throttles link_checked events on link-heavy pages
+const linkProgressInterval = 250 * time.Millisecond
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobFinished = errors.New("job already finished")
 )
 
 type AnalyzeURLUseCase struct {
-	Browser   core.BrowserProvider
+	Browser core.BrowserProvider
+	// Static fetches pages without a browser for render modes static and
+	// auto; without it every page is rendered by Browser
+	Static core.BrowserProvider
+	// Checker checks the links found on a page
+	Checker   *service.LinkChecker
 	Publisher core.ResultPublisher
+	Jobs      core.JobRepository
+	// Artifacts stores screenshots, HARs and, without PDFs, PDF exports;
+	// without it they are dropped
+	Artifacts core.ArtifactStore
+	// PDFs keeps PDF exports apart, usually for longer than Artifacts
+	PDFs core.ArtifactStore
+
+	mu sync.Mutex
+	// running holds the cancel function of every job currently executing
+	running map[string]context.CancelFunc
 }
 
-func (uc *AnalyzeURLUseCase) Execute(targetURL string, l *slog.Logger) {
-	html, err := uc.Browser.GetRenderedHTML(targetURL)
+// Execute renders and analyzes targetURL, publishing progress and the
+// outcome under jobID. It stops early when ctx or the job is cancelled.
+// The returned error is non-nil only if the terminal event could not be
+// published, i.e. the client never learned how the job ended.
+func (uc *AnalyzeURLUseCase) Execute(ctx context.Context, jobID, targetURL string, l *slog.Logger) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	opts, ok := uc.start(jobID, cancel)
+	if !ok {
+		// Cancel already published the cancelled event
+		l.Info("job cancelled before it started")
+		return nil
+	}
+	defer uc.stop(jobID)
+
+	uc.progress(ctx, l, model.Event{JobID: jobID, Type: model.EventRenderStarted})
+
+	page, fallback, err := uc.render(ctx, targetURL, opts, l)
+	if ctx.Err() != nil {
+		return uc.cancelled(jobID, l)
+	}
 	if err != nil {
 		l.Info("failed to get rendered HTML", "error", err.Error())
 
 		// 2. Prepare a specific "Unreachable" error result
 		errorResult := model.AnalysisResult{
-			URL: targetURL,
+			JobID: jobID,
+			URL:   targetURL,
 			Error: &model.ErrorDetail{
 				Message:    "The site could not be reached. Please check the URL and try again.",
 				StatusCode: 502,
 			},
 		}
+		if !uc.finish(jobID, model.JobFailed, &errorResult) {
+			return uc.cancelled(jobID, l)
+		}
 
 		// 3. Publish the error result so the UI updates
-		if pubErr := uc.Publisher.Publish(errorResult); pubErr != nil {
+		if pubErr := uc.publishTerminal(ctx, model.Event{JobID: jobID, Type: model.EventFailed, Data: errorResult}); pubErr != nil {
 			l.Error("Failed to publish error state", "error", pubErr)
+			return pubErr
 		}
-		return
+		return nil
 	}
+	uc.progress(ctx, l, model.Event{JobID: jobID, Type: model.EventRenderFinished, Data: page.Readiness})
+	l.Debug("page ready", "condition", page.Readiness.Condition, "waited_ms", page.Readiness.WaitedMS)
 
-	result, _ := service.ParseHTML(bytes.NewReader([]byte(html)))
+	result, _ := service.ParseHTML(bytes.NewReader([]byte(page.HTML)))
+	result.JobID = jobID
 	result.URL = targetURL
+	// Links are resolved and classified against where the page ended up
+	base := finalURL(page, targetURL)
+	result.FinalURL = base
+	result.Status = page.Status
+	result.Redirects = page.Redirects
+	result.RenderMode = page.Mode
+	result.RenderFallback = fallback
+	result.Readiness = &page.Readiness
+	result.Screenshot = uc.saveScreenshot(jobID, "screenshot", page, l)
+	result.PDF = uc.savePDF(jobID, page.PDF, l)
+	if page.Mode != model.RenderStatic {
+		console := service.SummarizeConsole(page.Console)
+		result.Console = &console
+		performance := service.RatePerformance(page.Vitals)
+		result.Performance = &performance
+	}
+	weight := service.MeasurePageWeight(base, page.Network)
+	result.PageWeight = &weight
+	if opts.HAR {
+		result.Network = uc.summarizeNetwork(jobID, targetURL, result.PageTitle, page, l)
+	}
+	uc.progress(ctx, l, model.Event{JobID: jobID, Type: model.EventParseDone, Data: model.ParseSummary{
+		PageTitle:     result.PageTitle,
+		HeadingCounts: result.HeadingCounts,
+		LinkCount:     len(result.DiscoveredLinks),
+		HasLoginForm:  result.HasLoginForm,
+	}})
+
+	uc.Jobs.Update(jobID, func(job *model.Job) {
+		if job.Status == model.JobRendering {
+			job.Status = model.JobCheckingLinks
+		}
+	})
+
+	var lastProgress time.Time
+	links := uc.Checker.ProcessLinks(ctx, base, result.DiscoveredLinks, !opts.IgnoreRobots, func(checked, total int) {
+		if checked < total && time.Since(lastProgress) < linkProgressInterval {
+			return
+		}
+		lastProgress = time.Now()
+		uc.progress(ctx, l, model.Event{JobID: jobID, Type: model.EventLinkChecked, Data: model.LinkProgress{Checked: checked, Total: total}})
+	})
+	if ctx.Err() != nil {
+		return uc.cancelled(jobID, l)
+	}
+	countLinks(&result.Links, links)
+	linkPage := model.PageLinks(links, 0, model.LinkPageSize)
+	result.LinkDetails = &linkPage
+	uc.Jobs.Update(jobID, func(job *model.Job) { job.Links = links })
+
+	if opts.CompareMobile {
+		result.MobileComparison = uc.compareMobile(ctx, jobID, targetURL, opts, result, l)
+		if ctx.Err() != nil {
+			return uc.cancelled(jobID, l)
+		}
+	}
+	if opts.CompareStatic && page.Mode != model.RenderStatic {
+		result.StaticComparison = uc.compareStatic(ctx, targetURL, opts, result, l)
+		if ctx.Err() != nil {
+			return uc.cancelled(jobID, l)
+		}
+	}
+	if !uc.finish(jobID, model.JobDone, result) {
+		// Cancelled after the last check; the client asked for that outcome
+		return uc.cancelled(jobID, l)
+	}
+
+	if err := uc.publishTerminal(ctx, model.Event{JobID: jobID, Type: model.EventResult, Data: result}); err != nil {
+		l.Error("Result delivery failed", "error", err, "url", targetURL)
+		return err
+	}
+	l.Info("Result delivered to socket server", "url", targetURL)
+	return nil
+}
+
+// render gets the page the way opts.Render asks for. In auto mode the page
+// is fetched statically first and rendered in the browser if that fails or
+// only returns an app shell; fallback then says why.
+func (uc *AnalyzeURLUseCase) render(ctx context.Context, targetURL string, opts model.RenderOptions, l *slog.Logger) (page *model.RenderedPage, fallback string, err error) {
+	mode := opts.RenderModeOrDefault()
+	if uc.Static == nil || mode == model.RenderBrowser || (mode == model.RenderAuto && opts.NeedsBrowser()) {
+		page, err = uc.Browser.GetRenderedHTML(ctx, targetURL, opts)
+		return page, "", err
+	}
+
+	page, err = uc.Static.GetRenderedHTML(ctx, targetURL, opts)
+	if mode == model.RenderStatic || ctx.Err() != nil {
+		return page, "", err
+	}
+	if err != nil {
+		fallback = "static fetch failed"
+		l.Info("static fetch failed, rendering in the browser", "error", err.Error())
+	} else {
+		fallback = service.DetectSPAShell(page.HTML)
+		if fallback == "" {
+			return page, "", nil
+		}
+		l.Debug("static page looks like an app shell, rendering in the browser", "reason", fallback)
+	}
+	page, err = uc.Browser.GetRenderedHTML(ctx, targetURL, opts)
+	return page, fallback, err
+}
+
+// compareMobile renders targetURL again as a mobile device and diffs it
+// against the desktop result. A failed mobile render is reported in the
+// comparison instead of failing the job.
+func (uc *AnalyzeURLUseCase) compareMobile(ctx context.Context, jobID, targetURL string, opts model.RenderOptions, desktop *model.AnalysisResult, l *slog.Logger) *model.MobileComparison {
+	mobileOpts := opts.MobileVariant()
+	comparison := &model.MobileComparison{Emulation: mobileOpts.EmulationOrDefault()}
+
+	page, err := uc.Browser.GetRenderedHTML(ctx, targetURL, mobileOpts)
+	if err != nil {
+		l.Info("failed to render mobile variant", "error", err.Error())
+		comparison.Error = &model.ErrorDetail{StatusCode: 502, Message: "The mobile version of the page could not be rendered."}
+		return comparison
+	}
+	mobile, err := service.ParseHTML(bytes.NewReader([]byte(page.HTML)))
+	if err != nil {
+		comparison.Error = &model.ErrorDetail{StatusCode: 500, Message: "The mobile version of the page could not be parsed."}
+		return comparison
+	}
+
+	comparison.Screenshot = uc.saveScreenshot(jobID, "screenshot-mobile", page, l)
+	mobileURL := finalURL(page, targetURL)
+	countLinks(&comparison.Links, uc.Checker.ProcessLinks(ctx, mobileURL, mobile.DiscoveredLinks, !opts.IgnoreRobots, nil))
+	comparison.PageTitle = mobile.PageTitle
+	comparison.HeadingCounts = mobile.HeadingCounts
+	comparison.HasLoginForm = mobile.HasLoginForm
+	diff := service.DiffPages(desktop.FinalURL, mobileURL, desktop, mobile)
+	comparison.Diff = &diff
+	return comparison
+}
+
+// compareStatic fetches targetURL without running scripts and diffs it
+// against the rendered result, showing what JavaScript changed. A failed
+// fetch is reported in the comparison instead of failing the job.
+func (uc *AnalyzeURLUseCase) compareStatic(ctx context.Context, targetURL string, opts model.RenderOptions, rendered *model.AnalysisResult, l *slog.Logger) *model.StaticComparison {
+	comparison := &model.StaticComparison{}
+	if uc.Static == nil {
+		comparison.Error = &model.ErrorDetail{StatusCode: 501, Message: "Static fetching is not available."}
+		return comparison
+	}
+
+	page, err := uc.Static.GetRenderedHTML(ctx, targetURL, opts)
+	if err != nil {
+		l.Info("failed to fetch static page", "error", err.Error())
+		comparison.Error = &model.ErrorDetail{StatusCode: 502, Message: "The page could not be fetched without a browser."}
+		return comparison
+	}
+	static, err := service.ParseHTML(bytes.NewReader([]byte(page.HTML)))
+	if err != nil {
+		comparison.Error = &model.ErrorDetail{StatusCode: 500, Message: "The static version of the page could not be parsed."}
+		return comparison
+	}
+
+	comparison.PageTitle = static.PageTitle
+	comparison.HeadingCounts = static.HeadingCounts
+	comparison.LinkCount = len(static.DiscoveredLinks)
+	comparison.FormCount = static.FormCount
+	comparison.HasLoginForm = static.HasLoginForm
+	diff := service.DiffPages(finalURL(page, targetURL), rendered.FinalURL, static, rendered)
+	comparison.Diff = &diff
+	return comparison
+}
+
+// finalURL is where page ended up after redirects, or targetURL if the
+// provider could not tell
+func finalURL(page *model.RenderedPage, targetURL string) string {
+	if page.FinalURL == "" {
+		return targetURL
+	}
+	return page.FinalURL
+}
 
-	links := service.ProcessLinks(targetURL, result.DiscoveredLinks)
+// saveScreenshot stores the screenshot of page, if any, as name
+func (uc *AnalyzeURLUseCase) saveScreenshot(jobID, name string, page *model.RenderedPage, l *slog.Logger) *model.Artifact {
+	if page.Screenshot == nil {
+		return nil
+	}
+	return uc.saveArtifact(jobID, name+"."+page.Screenshot.Extension(), page.Screenshot.Data, l)
+}
+
+// saveArtifact stores data, if any, as name. An artifact that cannot be
+// stored is left out of the result rather than failing the job.
+func (uc *AnalyzeURLUseCase) saveArtifact(jobID, name string, data []byte, l *slog.Logger) *model.Artifact {
+	if len(data) == 0 || uc.Artifacts == nil {
+		return nil
+	}
+	artifact, err := uc.Artifacts.Save(jobID, name, data)
+	if err != nil {
+		l.Error("failed to store artifact", "name", name, "error", err)
+		return nil
+	}
+	return &artifact
+}
+
+// savePDF stores the PDF export of a job in PDFs, or in Artifacts if PDFs
+// are not kept apart
+func (uc *AnalyzeURLUseCase) savePDF(jobID string, data []byte, l *slog.Logger) *model.Artifact {
+	if uc.PDFs == nil {
+		return uc.saveArtifact(jobID, "page.pdf", data, l)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	artifact, err := uc.PDFs.Save(jobID, "page.pdf", data)
+	if err != nil {
+		l.Error("failed to store PDF", "error", err)
+		return nil
+	}
+	return &artifact
+}
+
+// summarizeNetwork stores the HAR of the render and summarizes it
+func (uc *AnalyzeURLUseCase) summarizeNetwork(jobID, targetURL, title string, page *model.RenderedPage, l *slog.Logger) *model.NetworkSummary {
+	summary := service.SummarizeNetwork(page.Network, page.PageTimings)
+	har, err := json.Marshal(service.BuildHAR(targetURL, title, page.Network, page.PageTimings))
+	if err != nil {
+		l.Error("failed to encode HAR", "error", err)
+		return &summary
+	}
+	summary.HAR = uc.saveArtifact(jobID, "network.har", har, l)
+	return &summary
+}
+
+func countLinks(stats *model.LinkStats, links []model.LinkInfo) {
 	for _, li := range links {
 		if li.IsExternal {
-			result.Links.ExternalCount++
+			stats.ExternalCount++
 		} else {
-			result.Links.InternalCount++
+			stats.InternalCount++
 		}
-		if !li.Accessible {
-			result.Links.Inaccessible++
+		switch {
+		case li.Skipped != "":
+			stats.Skipped++
+		case !li.Accessible:
+			stats.Inaccessible++
 		}
 	}
-	if err := uc.Publisher.Publish(result); err != nil {
-		l.Error("Result delivery failed", "error", err, "url", targetURL)
-	} else {
-		l.Info("Result delivered to socket server", "url", targetURL)
+}
+
+// Cancel stops a queued or running job. The job is marked cancelled right
+// away; a running Execute notices through its context and publishes the
+// cancelled event, otherwise Cancel publishes it.
+func (uc *AnalyzeURLUseCase) Cancel(jobID string) error {
+	var finished bool
+	found := uc.Jobs.Update(jobID, func(job *model.Job) {
+		if job.Status.IsTerminal() {
+			finished = true
+			return
+		}
+		now := time.Now()
+		job.Status = model.JobCancelled
+		job.FinishedAt = &now
+	})
+	if !found {
+		return ErrJobNotFound
+	}
+	if finished {
+		return ErrJobFinished
+	}
+
+	uc.mu.Lock()
+	cancel, ok := uc.running[jobID]
+	uc.mu.Unlock()
+	if ok {
+		cancel()
+		return nil
+	}
+	uc.publishCancelled(jobID, slog.Default().With("job_id", jobID))
+	return nil
+}
+
+// start moves a queued job to rendering, registers its cancel function and
+// returns the job's render options. It returns false if the job was
+// cancelled before it could start.
+func (uc *AnalyzeURLUseCase) start(jobID string, cancel context.CancelFunc) (model.RenderOptions, bool) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	var opts model.RenderOptions
+	started := false
+	uc.Jobs.Update(jobID, func(job *model.Job) {
+		if job.Status != model.JobQueued {
+			return
+		}
+		now := time.Now()
+		job.Status = model.JobRendering
+		job.StartedAt = &now
+		opts = job.Options
+		started = true
+	})
+	if !started {
+		return opts, false
+	}
+	if uc.running == nil {
+		uc.running = make(map[string]context.CancelFunc)
+	}
+	uc.running[jobID] = cancel
+	return opts, true
+}
+
+func (uc *AnalyzeURLUseCase) stop(jobID string) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	delete(uc.running, jobID)
+}
+
+// cancelled records that a running job stopped because it was cancelled
+func (uc *AnalyzeURLUseCase) cancelled(jobID string, l *slog.Logger) error {
+	uc.Jobs.Update(jobID, func(job *model.Job) {
+		if job.Status.IsTerminal() {
+			return
+		}
+		now := time.Now()
+		job.Status = model.JobCancelled
+		job.FinishedAt = &now
+	})
+	l.Info("job cancelled")
+	return uc.publishCancelled(jobID, l)
+}
+
+// publishCancelled publishes the terminal event of a cancelled job
+func (uc *AnalyzeURLUseCase) publishCancelled(jobID string, l *slog.Logger) error {
+	if err := uc.publishTerminal(context.Background(), model.Event{JobID: jobID, Type: model.EventCancelled}); err != nil {
+		l.Error("Failed to publish cancelled state", "error", err)
+		return err
 	}
+	return nil
+}
+
+// terminalPublishTimeout bounds the delivery of the event that ends a job
+const terminalPublishTimeout = 5 * time.Second
+
+// publishTerminal publishes the event that ends a job. A cancel of the job
+// must not stop it, so it runs detached from ctx with its own timeout.
+func (uc *AnalyzeURLUseCase) publishTerminal(ctx context.Context, event model.Event) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), terminalPublishTimeout)
+	defer cancel()
+	return uc.Publisher.Publish(ctx, event)
+}
+
+// Republish publishes the terminal event of a job that already ended again,
+// for a client that may have missed it
+func (uc *AnalyzeURLUseCase) Republish(ctx context.Context, job model.Job) error {
+	event := model.Event{JobID: job.ID}
+	switch job.Status {
+	case model.JobDone:
+		event.Type, event.Data = model.EventResult, job.Result
+	case model.JobFailed:
+		event.Type, event.Data = model.EventFailed, model.AnalysisResult{JobID: job.ID, URL: job.URL, Error: job.Error}
+	case model.JobCancelled:
+		event.Type = model.EventCancelled
+	default:
+		return fmt.Errorf("job %s has not ended", job.ID)
+	}
+	return uc.publishTerminal(ctx, event)
+}
+
+// progress publishes a non-terminal event; a lost progress update is not
+// worth failing the job for
+func (uc *AnalyzeURLUseCase) progress(ctx context.Context, l *slog.Logger, event model.Event) {
+	if err := uc.Publisher.Publish(ctx, event); err != nil {
+		l.Debug("Progress delivery failed", "error", err, "type", event.Type)
+	}
+}
+
+// finish records the terminal state of a job before its result is published.
+// A job cancelled in the meantime keeps its cancelled status, and finish
+// returns false.
+func (uc *AnalyzeURLUseCase) finish(jobID string, status model.JobStatus, result *model.AnalysisResult) bool {
+	finished := false
+	uc.Jobs.Update(jobID, func(job *model.Job) {
+		if job.Status.IsTerminal() {
+			return
+		}
+		finished = true
+		now := time.Now()
+		job.Status = status
+		job.FinishedAt = &now
+		if result.Error != nil {
+			job.Error = result.Error
+		} else {
+			job.Result = result
+		}
+	})
+	return finished
 }
@@ -0,0 +1,225 @@
+package analysis
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"headlessBrowser-worker/adapter/repository"
+	"headlessBrowser-worker/domain/model"
+	"headlessBrowser-worker/domain/service"
+)
+
+// blockingBrowser never finishes rendering until its context is cancelled
+type blockingBrowser struct{ started chan struct{} }
+
+func (b *blockingBrowser) GetRenderedHTML(ctx context.Context, url string, opts model.RenderOptions) (*model.RenderedPage, error) {
+	close(b.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []model.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event model.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) last() model.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.events[len(p.events)-1]
+}
+
+func newTestUseCase() (*AnalyzeURLUseCase, *recordingPublisher, *blockingBrowser) {
+	browser := &blockingBrowser{started: make(chan struct{})}
+	publisher := &recordingPublisher{}
+	uc := &AnalyzeURLUseCase{
+		Browser:   browser,
+		Publisher: publisher,
+		Jobs:      repository.NewMemoryJobRepository(time.Hour),
+		Checker:   service.NewLinkChecker(service.LinkCheckerConfig{}),
+	}
+	return uc, publisher, browser
+}
+
+func TestCancel_RunningJob(t *testing.T) {
+	uc, publisher, browser := newTestUseCase()
+	job := model.NewJob("https://example.com")
+	uc.Jobs.Create(job)
+
+	done := make(chan struct{})
+	go func() {
+		uc.Execute(context.Background(), job.ID, job.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		close(done)
+	}()
+	<-browser.started
+
+	if err := uc.Cancel(job.ID); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Execute did not stop after cancel")
+	}
+
+	if got, _ := uc.Jobs.Get(job.ID); got.Status != model.JobCancelled {
+		t.Errorf("expected status %q, got %q", model.JobCancelled, got.Status)
+	}
+	if ev := publisher.last(); ev.Type != model.EventCancelled {
+		t.Errorf("expected last event %q, got %q", model.EventCancelled, ev.Type)
+	}
+	if err := uc.Cancel(job.ID); err != ErrJobFinished {
+		t.Errorf("expected ErrJobFinished on second cancel, got %v", err)
+	}
+}
+
+func TestCancel_QueuedJobNeverStarts(t *testing.T) {
+	uc, publisher, browser := newTestUseCase()
+	job := model.NewJob("https://example.com")
+	uc.Jobs.Create(job)
+
+	if err := uc.Cancel(job.ID); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	uc.Execute(context.Background(), job.ID, job.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	select {
+	case <-browser.started:
+		t.Error("expected cancelled job not to render")
+	default:
+	}
+	if len(publisher.events) != 1 || publisher.events[0].Type != model.EventCancelled {
+		t.Errorf("expected a single %q event, got %v", model.EventCancelled, publisher.events)
+	}
+	if err := uc.Cancel("missing"); err != ErrJobNotFound {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+}
+
+// deviceBrowser serves a trimmed-down page to mobile renders
+type deviceBrowser struct{}
+
+func (deviceBrowser) GetRenderedHTML(ctx context.Context, url string, opts model.RenderOptions) (*model.RenderedPage, error) {
+	if opts.Emulation != nil && opts.Emulation.Mobile {
+		return &model.RenderedPage{HTML: `<title>Shop</title><h1>Shop</h1>`}, nil
+	}
+	return &model.RenderedPage{HTML: `<title>Shop</title><h1>Shop</h1><nav><a href="mailto:shop@example.com">Mail</a></nav><form><input type="password"></form>`}, nil
+}
+
+func TestExecute_CompareMobile(t *testing.T) {
+	uc, publisher, _ := newTestUseCase()
+	uc.Browser = deviceBrowser{}
+	job := model.NewJob("https://example.com")
+	job.Options.CompareMobile = true
+	uc.Jobs.Create(job)
+
+	uc.Execute(context.Background(), job.ID, job.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ev := publisher.last()
+	if ev.Type != model.EventResult {
+		t.Fatalf("expected %q event, got %q", model.EventResult, ev.Type)
+	}
+	comparison := ev.Data.(*model.AnalysisResult).MobileComparison
+	if comparison == nil || comparison.Diff == nil {
+		t.Fatalf("expected a mobile comparison, got %+v", comparison)
+	}
+	if !comparison.Emulation.Mobile {
+		t.Error("expected the mobile render to use the mobile preset")
+	}
+	diff := comparison.Diff
+	if len(diff.LinksOnlyInBaseline) != 1 || diff.LinksOnlyInBaseline[0] != "mailto:shop@example.com" {
+		t.Errorf("expected the nav link to be desktop-only, got %v", diff.LinksOnlyInBaseline)
+	}
+	if diff.LoginFormOnlyIn != "baseline" {
+		t.Errorf("expected login form only on desktop, got %q", diff.LoginFormOnlyIn)
+	}
+	if diff.Title != nil || diff.Identical {
+		t.Errorf("unexpected diff %+v", diff)
+	}
+}
+
+// staticBrowser serves fixed HTML and counts how often it was asked
+type staticBrowser struct {
+	html  string
+	mode  model.RenderMode
+	calls int
+}
+
+func (b *staticBrowser) GetRenderedHTML(ctx context.Context, url string, opts model.RenderOptions) (*model.RenderedPage, error) {
+	b.calls++
+	return &model.RenderedPage{HTML: b.html, Mode: b.mode}, nil
+}
+
+func TestExecute_AutoRender(t *testing.T) {
+	tests := []struct {
+		name         string
+		staticHTML   string
+		wantMode     model.RenderMode
+		wantFallback bool
+	}{
+		{"static page", `<title>Docs</title><h1>Docs</h1><p>` + strings.Repeat("Server-rendered text. ", 20) + `</p>`, model.RenderStatic, false},
+		{"app shell", `<title>App</title><div id="root"></div><script src="/app.js"></script>`, model.RenderBrowser, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, publisher, _ := newTestUseCase()
+			chrome := &staticBrowser{html: `<title>App</title><h1>Hydrated</h1>`, mode: model.RenderBrowser}
+			uc.Browser = chrome
+			uc.Static = &staticBrowser{html: tt.staticHTML, mode: model.RenderStatic}
+			job := model.NewJob("https://example.com")
+			job.Options.Render = model.RenderAuto
+			uc.Jobs.Create(job)
+
+			uc.Execute(context.Background(), job.ID, job.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			result := publisher.last().Data.(*model.AnalysisResult)
+			if result.RenderMode != tt.wantMode || (result.RenderFallback != "") != tt.wantFallback {
+				t.Errorf("expected mode %s (fallback %v), got %s %q", tt.wantMode, tt.wantFallback, result.RenderMode, result.RenderFallback)
+			}
+			if tt.wantMode == model.RenderStatic && (chrome.calls != 0 || result.Performance != nil) {
+				t.Errorf("expected no browser render, got %d calls", chrome.calls)
+			}
+		})
+	}
+}
+
+func TestExecute_CompareStatic(t *testing.T) {
+	uc, publisher, _ := newTestUseCase()
+	uc.Browser = &staticBrowser{html: `<title>Shop | Cart</title><h1>Shop</h1><h2>Cart</h2><a href="mailto:orders@example.com">Order by mail</a><form></form>`, mode: model.RenderBrowser}
+	uc.Static = &staticBrowser{html: `<title>Shop</title><h1>Shop</h1><div id="root"></div>`, mode: model.RenderStatic}
+	job := model.NewJob("https://example.com")
+	job.Options.CompareStatic = true
+	uc.Jobs.Create(job)
+
+	uc.Execute(context.Background(), job.ID, job.URL, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	comparison := publisher.last().Data.(*model.AnalysisResult).StaticComparison
+	if comparison == nil || comparison.Diff == nil {
+		t.Fatalf("expected a static comparison, got %+v", comparison)
+	}
+	diff := comparison.Diff
+	if diff.Title == nil || diff.Title.Baseline != "Shop" || diff.Title.Variant != "Shop | Cart" {
+		t.Errorf("expected the title changed by JavaScript, got %+v", diff.Title)
+	}
+	if diff.Headings["h2"] != (model.CountDiff{Baseline: 0, Variant: 1}) {
+		t.Errorf("expected an injected h2, got %v", diff.Headings)
+	}
+	if len(diff.LinksOnlyInVariant) != 1 || diff.LinksOnlyInVariant[0] != "mailto:orders@example.com" {
+		t.Errorf("expected the mail link only after rendering, got %v", diff.LinksOnlyInVariant)
+	}
+	if diff.Forms == nil || diff.Forms.Variant != 1 {
+		t.Errorf("expected a form added by hydration, got %+v", diff.Forms)
+	}
+}
@@ -0,0 +1,145 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"headlessBrowser-worker/domain/model"
+	"log/slog"
+	"sync"
+)
+
+var (
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrJobExists is returned for a client-chosen job ID that is in use
+	ErrJobExists = errors.New("job_id is already in use")
+)
+
+type queuedJob struct {
+	jobID string
+	url   string
+	l     *slog.Logger
+	// done, if set, is called once the job has finished or left the queue
+	done func(err error)
+}
+
+// JobQueue is a bounded FIFO of analysis jobs drained by a fixed number of
+// workers, so a burst of requests never starts more Chrome processes than
+// there are workers.
+type JobQueue struct {
+	UseCase *AnalyzeURLUseCase
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []queuedJob
+	maxSize int
+	closed  bool
+}
+
+func NewJobQueue(uc *AnalyzeURLUseCase, maxSize int) *JobQueue {
+	q := &JobQueue{UseCase: uc, maxSize: maxSize}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Start launches the workers; they exit once ctx is cancelled
+func (q *JobQueue) Start(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		go q.work(ctx)
+	}
+	go func() {
+		<-ctx.Done()
+		q.mu.Lock()
+		q.closed = true
+		q.mu.Unlock()
+		q.cond.Broadcast()
+	}()
+}
+
+// Submit registers a new job and appends it to the queue, returning its
+// 1-based position. It fails with ErrQueueFull once maxSize jobs are waiting,
+// and with ErrJobExists if a job with the same ID is known; in both cases
+// the job is not registered. done may be nil; otherwise it receives the
+// error returned by Execute, or nil if the job was cancelled while waiting.
+func (q *JobQueue) Submit(job *model.Job, l *slog.Logger, done func(err error)) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, exists := q.UseCase.Jobs.Get(job.ID); exists {
+		return 0, ErrJobExists
+	}
+	if len(q.pending) >= q.maxSize {
+		return 0, ErrQueueFull
+	}
+	q.UseCase.Jobs.Create(job)
+	q.pending = append(q.pending, queuedJob{jobID: job.ID, url: job.URL, l: l, done: done})
+	q.cond.Signal()
+	return len(q.pending), nil
+}
+
+// Position returns the 1-based position of a waiting job and the current
+// queue depth. Position is 0 if the job is not waiting.
+func (q *JobQueue) Position(jobID string) (position, depth int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, job := range q.pending {
+		if job.jobID == jobID {
+			return i + 1, len(q.pending)
+		}
+	}
+	return 0, len(q.pending)
+}
+
+// Depth returns the number of jobs waiting for a worker
+func (q *JobQueue) Depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Cancel drops a waiting job from the queue and cancels it in the use case,
+// which also stops it if a worker already picked it up
+func (q *JobQueue) Cancel(jobID string) error {
+	var removed *queuedJob
+	q.mu.Lock()
+	for i, job := range q.pending {
+		if job.jobID == jobID {
+			removed = &job
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			break
+		}
+	}
+	q.mu.Unlock()
+
+	err := q.UseCase.Cancel(jobID)
+	if removed != nil && removed.done != nil {
+		removed.done(nil)
+	}
+	return err
+}
+
+func (q *JobQueue) work(ctx context.Context) {
+	for {
+		job, ok := q.next()
+		if !ok {
+			return
+		}
+		err := q.UseCase.Execute(ctx, job.jobID, job.url, job.l)
+		if job.done != nil {
+			job.done(err)
+		}
+	}
+}
+
+// next blocks until a job is available or the queue is closed
+func (q *JobQueue) next() (queuedJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.pending) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return queuedJob{}, false
+	}
+	job := q.pending[0]
+	q.pending = q.pending[1:]
+	return job, true
+}
@@ -0,0 +1,46 @@
+package analysis
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+func TestJobQueue_BoundedWithPositions(t *testing.T) {
+	uc, _, _ := newTestUseCase()
+	q := NewJobQueue(uc, 2)
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	first, second := model.NewJob("https://a.example"), model.NewJob("https://b.example")
+
+	if pos, err := q.Submit(first, l, nil); err != nil || pos != 1 {
+		t.Fatalf("expected position 1, got %d (%v)", pos, err)
+	}
+	if pos, err := q.Submit(second, l, nil); err != nil || pos != 2 {
+		t.Fatalf("expected position 2, got %d (%v)", pos, err)
+	}
+	again := model.NewJob("https://a.example")
+	again.ID = first.ID
+	if _, err := q.Submit(again, l, nil); err != ErrJobExists {
+		t.Fatalf("expected ErrJobExists for a reused ID, got %v", err)
+	}
+	third := model.NewJob("https://c.example")
+	if _, err := q.Submit(third, l, nil); err != ErrQueueFull {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	if _, ok := uc.Jobs.Get(third.ID); ok {
+		t.Error("expected rejected job not to be registered")
+	}
+
+	if err := q.Cancel(first.ID); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	if pos, depth := q.Position(second.ID); pos != 1 || depth != 1 {
+		t.Errorf("expected second job to move to 1 of 1, got %d of %d", pos, depth)
+	}
+	if pos, _ := q.Position(first.ID); pos != 0 {
+		t.Errorf("expected cancelled job to leave the queue, got position %d", pos)
+	}
+}
@@ -1,9 +1,37 @@
 package core
 
+import (
+	"context"
+	"errors"
+	"headlessBrowser-worker/domain/model"
+	"io"
+)
+
+// BrowserProvider renders a page; it must stop as soon as ctx is cancelled
 type BrowserProvider interface {
-	GetRenderedHTML(url string) (string, error)
+	GetRenderedHTML(ctx context.Context, url string, opts model.RenderOptions) (*model.RenderedPage, error)
 }
 
+// ResultPublisher delivers progress and result events to the client of a job
 type ResultPublisher interface {
-	Publish(result interface{}) error
+	Publish(ctx context.Context, event model.Event) error
+}
+
+// ErrArtifactNotFound is returned by ArtifactStore.Open for unknown artifacts
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+// ArtifactStore keeps the files produced by a job, such as screenshots
+type ArtifactStore interface {
+	// Save stores data as name under jobID and returns where it is served
+	Save(jobID, name string, data []byte) (model.Artifact, error)
+	Open(jobID, name string) (io.ReadSeekCloser, model.Artifact, error)
+}
+
+// JobRepository stores analysis jobs so their status can be queried later
+type JobRepository interface {
+	Create(job *model.Job)
+	Get(id string) (model.Job, bool)
+	List() []model.Job
+	// Update applies fn to the stored job; it returns false if id is unknown
+	Update(id string, fn func(job *model.Job)) bool
 }
@@ -2,40 +2,96 @@ package main
 
 import (
 	"common/logger"
+	"context"
 	"headlessBrowser-worker/adapter/external"
+	"headlessBrowser-worker/adapter/messaging"
+	"headlessBrowser-worker/adapter/repository"
 	"headlessBrowser-worker/api/http/handle"
 	"headlessBrowser-worker/api/middleware"
 	"headlessBrowser-worker/application/analysis"
+	"headlessBrowser-worker/application/core"
+	"headlessBrowser-worker/config"
+	"headlessBrowser-worker/domain/service"
 	"log/slog"
 	"net/http"
+	"os"
 	"time"
+	// Embedded so emulated timezones validate in slim images without tzdata
+	_ "time/tzdata"
 
+	"github.com/nats-io/nats.go"
 	"github.com/rs/cors"
 )
 
 func main() {
 	logger.InitLogger(logger.Config{ServiceName: "headless-worker", Level: slog.LevelDebug})
+	cfg := config.Load()
 
 	// Dependency Manual Injection
-	chrome := &external.ChromeAdapter{}
-	publisher := &external.SocketAdapter{Endpoint: "http://socket-service:8081/publish"}
-	useCase := &analysis.AnalyzeURLUseCase{Browser: chrome, Publisher: publisher}
-	handler := &handle.AnalysisHandler{UseCase: useCase}
+	pool := external.NewBrowserPool(cfg.BrowserPoolSize, cfg.BrowserMaxPages)
+	defer pool.Close()
+	chrome := &external.ChromeAdapter{Pool: pool, MaxBytes: int64(cfg.MaxDocumentBytes)}
+	static := external.NewStaticAdapter(int64(cfg.MaxDocumentBytes))
+	var nc *nats.Conn
+	if cfg.Publisher == "nats" || cfg.ConsumeNATSJobs {
+		nc = connectNATS(cfg)
+	}
+	publisher := newPublisher(cfg, nc)
+	jobs := repository.NewMemoryJobRepository(cfg.JobRetention)
+	artifacts, err := repository.NewLocalArtifactStore(cfg.ArtifactDir, cfg.ArtifactBaseURL, cfg.JobRetention)
+	if err != nil {
+		slog.Error("artifact store setup failed", "dir", cfg.ArtifactDir, "error", err)
+		os.Exit(1)
+	}
+	pdfs, err := repository.NewLocalArtifactStore(cfg.PDFDir, cfg.ArtifactBaseURL, cfg.PDFRetention)
+	if err != nil {
+		slog.Error("PDF store setup failed", "dir", cfg.PDFDir, "error", err)
+		os.Exit(1)
+	}
+	checker := service.NewLinkChecker(service.LinkCheckerConfig{
+		Timeout:            cfg.LinkCheckTimeout,
+		MaxRedirects:       cfg.LinkMaxRedirects,
+		RedirectPolicy:     cfg.LinkRedirectPolicy,
+		PerHostConcurrency: cfg.LinkConcurrencyPerHost,
+		PerHostRPS:         cfg.LinkRPSPerHost,
+		MaxAttempts:        cfg.LinkMaxAttempts,
+		RetryBaseDelay:     cfg.LinkRetryBaseDelay,
+		MaxRetryDelay:      cfg.LinkMaxRetryDelay,
+		RobotsAgent:        cfg.RobotsUserAgent,
+		BotUserAgent:       cfg.BotUserAgent,
+		CrawlDelayBudget:   cfg.CrawlDelayBudget,
+	})
+	useCase := &analysis.AnalyzeURLUseCase{Browser: chrome, Static: static, Checker: checker, Publisher: publisher, Jobs: jobs, Artifacts: artifacts, PDFs: pdfs}
+	queue := analysis.NewJobQueue(useCase, cfg.QueueSize)
+	queue.Start(context.Background(), cfg.QueueWorkers)
+	handler := &handle.AnalysisHandler{Queue: queue, RetryAfter: cfg.QueueRetryAfter}
+	jobHandler := &handle.JobHandler{Jobs: jobs, Queue: queue}
+	artifactHandler := &handle.ArtifactHandler{Store: artifacts, PDFs: pdfs}
+
+	if cfg.ConsumeNATSJobs {
+		startJobConsumer(cfg, nc, queue)
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/analyze", handler.HandleAnalyze)
+	mux.HandleFunc("GET /jobs", jobHandler.HandleListJobs)
+	mux.HandleFunc("GET /jobs/{id}", jobHandler.HandleGetJob)
+	mux.HandleFunc("GET /jobs/{id}/links", jobHandler.HandleGetJobLinks)
+	mux.HandleFunc("DELETE /jobs/{id}", jobHandler.HandleCancelJob)
+	mux.HandleFunc("GET /artifacts/{job}/{name}", artifactHandler.HandleGetArtifact)
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(200) })
 
 	// Setup CORS options
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"http://localhost:3000"}, // Your React URL
-		AllowedMethods: []string{"POST", "OPTIONS"},
+		AllowedMethods: []string{"GET", "POST", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{"Content-Type", "Idempotency-Key"},
+		ExposedHeaders: []string{"Retry-After"},
 		Debug:          true, // Useful for troubleshooting
 	})
 
 	srv := &http.Server{
-		Addr:         ":8080",
+		Addr:         cfg.Addr,
 		Handler:      c.Handler(mux),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
@@ -55,3 +111,58 @@ func main() {
 	}
 
 }
+
+func connectNATS(cfg config.Config) *nats.Conn {
+	nc, err := messaging.Connect(cfg.NatsURL, "headless-worker")
+	if err != nil {
+		slog.Error("nats connect failed", "url", cfg.NatsURL, "error", err)
+		os.Exit(1)
+	}
+	return nc
+}
+
+// startJobConsumer subscribes to analyze.requests, durably through JetStream
+// when NATS_DURABLE_JOBS is set
+func startJobConsumer(cfg config.Config, nc *nats.Conn, queue *analysis.JobQueue) {
+	if !cfg.DurableJobs {
+		consumer := &messaging.NATSJobConsumer{Queue: queue}
+		if _, err := consumer.Start(nc); err != nil {
+			slog.Error("nats job consumer setup failed", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("consuming jobs from NATS", "subject", messaging.JobsSubject, "queue_group", messaging.JobsQueueGroup)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	consumer := &messaging.JetStreamJobConsumer{
+		Queue:         queue,
+		Workers:       cfg.QueueWorkers,
+		MaxDeliveries: cfg.MaxDeliveries,
+		AckWait:       cfg.JobAckWait,
+		RetryDelay:    cfg.JobRetryDelay,
+	}
+	if _, err := consumer.Start(ctx, nc); err != nil {
+		slog.Error("jetstream job consumer setup failed", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("consuming jobs from JetStream", "stream", messaging.JobsStream, "durable", messaging.JobsDurable, "max_deliveries", cfg.MaxDeliveries)
+}
+
+// newPublisher picks the ResultPublisher configured with PUBLISHER
+func newPublisher(cfg config.Config, nc *nats.Conn) core.ResultPublisher {
+	if cfg.Publisher != "nats" {
+		return external.NewSocketAdapter(cfg.SocketEndpoint, cfg.SocketTimeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	publisher, err := messaging.NewNATSPublisher(ctx, nc)
+	if err != nil {
+		slog.Error("nats publisher setup failed", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("publishing events over NATS", "url", cfg.NatsURL)
+	return publisher
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Config holds the runtime settings of the worker, read from the environment
+type Config struct {
+	Addr string
+	// Publisher selects how events reach the socket server: "http" or "nats"
+	Publisher      string
+	SocketEndpoint string
+	// SocketTimeout bounds each event posted to SocketEndpoint
+	SocketTimeout time.Duration
+	NatsURL       string
+	// ConsumeNATSJobs subscribes the worker to analyze.requests
+	ConsumeNATSJobs bool
+	// DurableJobs consumes analyze.requests from JetStream with acks and
+	// redelivery instead of a plain queue group
+	DurableJobs   bool
+	MaxDeliveries int
+	JobAckWait    time.Duration
+	JobRetryDelay time.Duration
+	JobRetention  time.Duration
+	// QueueWorkers is the number of jobs (and Chrome processes) run at once
+	QueueWorkers int
+	// QueueSize is how many jobs may wait before /analyze answers 429
+	QueueSize       int
+	QueueRetryAfter time.Duration
+	// BrowserPoolSize is the number of Chrome processes kept running; each
+	// is replaced after BrowserMaxPages pages
+	BrowserPoolSize int
+	BrowserMaxPages int
+	// MaxDocumentBytes limits the HTML of a page, fetched or rendered
+	MaxDocumentBytes int
+	// LinkCheckTimeout bounds each link check; LinkRedirectPolicy is
+	// follow, same_host or none
+	LinkCheckTimeout   time.Duration
+	LinkMaxRedirects   int
+	LinkRedirectPolicy string
+	// LinkConcurrencyPerHost and LinkRPSPerHost limit the link checks to
+	// one host across all jobs; transient failures are tried up to
+	// LinkMaxAttempts times with exponential backoff
+	LinkConcurrencyPerHost int
+	LinkRPSPerHost         float64
+	LinkMaxAttempts        int
+	LinkRetryBaseDelay     time.Duration
+	LinkMaxRetryDelay      time.Duration
+	// RobotsUserAgent is the token robots.txt rules are matched against;
+	// BotUserAgent, if set, replaces the User-Agent built from it
+	RobotsUserAgent string
+	BotUserAgent    string
+	// CrawlDelayBudget is how long a Crawl-delay may hold back the links of
+	// one host in a job
+	CrawlDelayBudget time.Duration
+	// ArtifactDir holds screenshots, HARs and other job files, served under
+	// ArtifactBaseURL; they are kept as long as JobRetention
+	ArtifactDir     string
+	ArtifactBaseURL string
+	// PDFDir holds PDF exports apart from other artifacts, so archived
+	// copies can live on durable storage; they are kept for PDFRetention,
+	// or until removed by hand if it is 0
+	PDFDir       string
+	PDFRetention time.Duration
+}
+
+// Load reads the configuration from environment variables, falling back to
+// the defaults used by docker-compose
+func Load() Config {
+	return Config{
+		Addr:                   getEnv("WORKER_ADDR", ":8080"),
+		Publisher:              getEnv("PUBLISHER", "http"),
+		SocketEndpoint:         getEnv("SOCKET_ENDPOINT", "http://socket-service:8081/publish"),
+		SocketTimeout:          getEnvDuration("SOCKET_PUBLISH_TIMEOUT", 5*time.Second),
+		NatsURL:                getEnv("NATS_URL", "nats://nats:4222"),
+		ConsumeNATSJobs:        getEnvBool("NATS_CONSUME_JOBS", false),
+		DurableJobs:            getEnvBool("NATS_DURABLE_JOBS", false),
+		MaxDeliveries:          getEnvInt("NATS_MAX_DELIVERIES", 3),
+		JobAckWait:             getEnvDuration("NATS_ACK_WAIT", 30*time.Second),
+		JobRetryDelay:          getEnvDuration("NATS_RETRY_DELAY", 5*time.Second),
+		JobRetention:           getEnvDuration("JOB_RETENTION", time.Hour),
+		QueueWorkers:           getEnvInt("QUEUE_WORKERS", 2),
+		QueueSize:              getEnvInt("QUEUE_SIZE", 20),
+		QueueRetryAfter:        getEnvDuration("QUEUE_RETRY_AFTER", 30*time.Second),
+		BrowserPoolSize:        getEnvInt("BROWSER_POOL_SIZE", 1),
+		BrowserMaxPages:        getEnvInt("BROWSER_MAX_PAGES", 50),
+		MaxDocumentBytes:       getEnvInt("MAX_DOCUMENT_BYTES", 20<<20),
+		LinkCheckTimeout:       getEnvDuration("LINK_CHECK_TIMEOUT", 5*time.Second),
+		LinkMaxRedirects:       getEnvInt("LINK_MAX_REDIRECTS", 10),
+		LinkRedirectPolicy:     getEnv("LINK_REDIRECT_POLICY", "follow"),
+		LinkConcurrencyPerHost: getEnvInt("LINK_CONCURRENCY_PER_HOST", 2),
+		LinkRPSPerHost:         getEnvFloat("LINK_RPS_PER_HOST", 5),
+		LinkMaxAttempts:        getEnvInt("LINK_MAX_ATTEMPTS", 3),
+		LinkRetryBaseDelay:     getEnvDuration("LINK_RETRY_BASE_DELAY", 500*time.Millisecond),
+		LinkMaxRetryDelay:      getEnvDuration("LINK_MAX_RETRY_DELAY", 30*time.Second),
+		RobotsUserAgent:        getEnv("ROBOTS_USER_AGENT", "snappy-analyzer"),
+		BotUserAgent:           getEnv("BOT_USER_AGENT", ""),
+		CrawlDelayBudget:       getEnvDuration("ROBOTS_CRAWL_DELAY_BUDGET", time.Minute),
+		ArtifactDir:            getEnv("ARTIFACT_DIR", filepath.Join(os.TempDir(), "snappy-artifacts")),
+		ArtifactBaseURL:        getEnv("ARTIFACT_BASE_URL", "http://localhost:8080"),
+		PDFDir:                 getEnv("PDF_DIR", filepath.Join(os.TempDir(), "snappy-pdfs")),
+		PDFRetention:           getEnvDuration("PDF_RETENTION", 30*24*time.Hour),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+func getEnvInt(key string, fallback int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	f, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || f <= 0 {
+		return fallback
+	}
+	return f
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	b, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return b
+}
@@ -2,15 +2,44 @@ package model
 
 // AnalysisResult holds the final data sent to the React frontend
 type AnalysisResult struct {
-	URL           string         `json:"url"`
+	JobID string `json:"job_id"`
+	URL   string `json:"url"`
+	// FinalURL is where URL ended up after Redirects; Status is the HTTP
+	// status of that document
+	FinalURL      string         `json:"final_url"`
+	Status        int            `json:"status,omitempty"`
+	Redirects     []RedirectHop  `json:"redirects,omitempty"`
 	HTMLVersion   string         `json:"html_version"`
 	PageTitle     string         `json:"page_title"`
 	HeadingCounts map[string]int `json:"heading_counts"`
 	Links         LinkStats      `json:"links"`
 	HasLoginForm  bool           `json:"has_login_form"`
-	Error         *ErrorDetail   `json:"error,omitempty"`
+	FormCount     int            `json:"form_count"`
+	// RenderMode is how the page was fetched, static or browser
+	RenderMode RenderMode `json:"render_mode,omitempty"`
+	// RenderFallback says why an auto mode job needed the browser
+	RenderFallback string     `json:"render_fallback,omitempty"`
+	Readiness      *Readiness `json:"readiness,omitempty"`
+	Screenshot     *Artifact  `json:"screenshot,omitempty"`
+	PDF            *Artifact  `json:"pdf,omitempty"`
+	// Network summarizes the requests of the render for jobs with har
+	Network *NetworkSummary `json:"network,omitempty"`
+	// Console lists the JavaScript errors and messages of the render
+	Console *ConsoleReport `json:"console,omitempty"`
+	// Performance rates the Core Web Vitals measured during the render
+	Performance *Performance `json:"performance,omitempty"`
+	// PageWeight breaks down the bytes the render downloaded
+	PageWeight *PageWeight `json:"page_weight,omitempty"`
+	// MobileComparison is set for jobs with compare_mobile
+	MobileComparison *MobileComparison `json:"mobile_comparison,omitempty"`
+	// StaticComparison is set for jobs with compare_static
+	StaticComparison *StaticComparison `json:"static_comparison,omitempty"`
+	Error            *ErrorDetail      `json:"error,omitempty"`
+	// LinkDetails is the first page of the checked links; the rest are
+	// served by GET /jobs/{id}/links
+	LinkDetails *LinkPage `json:"link_details,omitempty"`
 	// unexported field used during processing
-	DiscoveredLinks []string
+	DiscoveredLinks []DiscoveredLink `json:"-"`
 }
 
 // LinkStats holds the summarized counts
@@ -18,13 +47,82 @@ type LinkStats struct {
 	InternalCount int `json:"internal_count"`
 	ExternalCount int `json:"external_count"`
 	Inaccessible  int `json:"inaccessible"`
+	// Skipped counts links that were not requested because robots.txt
+	// disallows them or its Crawl-delay would take too long
+	Skipped int `json:"skipped"`
 }
 
-// LinkInfo is what your checker.go specifically is missing
+// DiscoveredLink is a link as found in the page
+type DiscoveredLink struct {
+	Href string
+	// Text is the anchor text, or the alt text of an image link
+	Text string
+	// Element describes the anchor, e.g. "nav a#home.menu"
+	Element string
+}
+
+// Error classes of a link check
+const (
+	LinkErrorDNS        = "dns"
+	LinkErrorTLS        = "tls"
+	LinkErrorTimeout    = "timeout"
+	LinkErrorRefused    = "refused"
+	LinkErrorHTTPStatus = "http_status"
+	LinkErrorOther      = "other"
+)
+
+// Skipped reasons of a link
+const (
+	// LinkSkippedRobots is a link disallowed by robots.txt
+	LinkSkippedRobots = "robots"
+	// LinkSkippedCrawlDelay is a link the Crawl-delay of its host would
+	// have held back too long
+	LinkSkippedCrawlDelay = "crawl_delay"
+)
+
+// LinkInfo is the outcome of checking one distinct link of the page
 type LinkInfo struct {
-	Address    string
-	IsExternal bool
-	Accessible bool
+	Address    string `json:"url"`
+	IsExternal bool   `json:"external"`
+	Accessible bool   `json:"accessible"`
+	// Skipped says why the link was not requested; Accessible is then
+	// unknown and left false
+	Skipped string `json:"skipped,omitempty"`
+	// StatusCode is 0 if no response arrived
+	StatusCode int `json:"status_code,omitempty"`
+	// ErrorClass says why an inaccessible link failed
+	ErrorClass string `json:"error_class,omitempty"`
+	Error      string `json:"error,omitempty"`
+	ResponseMS int64  `json:"response_ms"`
+	// Retries counts the attempts repeated after transient failures
+	Retries int `json:"retries,omitempty"`
+	// FinalURL is set when the link redirected elsewhere
+	FinalURL string `json:"final_url,omitempty"`
+	// Text and Element describe the first anchor with this link;
+	// Occurrences counts all of them
+	Text        string `json:"text,omitempty"`
+	Element     string `json:"element,omitempty"`
+	Occurrences int    `json:"occurrences"`
+}
+
+// LinkPageSize is how many links the result and the link API return by default
+const LinkPageSize = 100
+
+// LinkPage is a window of the checked links of a job
+type LinkPage struct {
+	Links  []LinkInfo `json:"links"`
+	Total  int        `json:"total"`
+	Offset int        `json:"offset"`
+	Limit  int        `json:"limit"`
+}
+
+// PageLinks returns the links from offset on, at most limit of them
+func PageLinks(links []LinkInfo, offset, limit int) LinkPage {
+	page := LinkPage{Links: []LinkInfo{}, Total: len(links), Offset: offset, Limit: limit}
+	if offset < len(links) {
+		page.Links = links[offset:min(offset+limit, len(links))]
+	}
+	return page
 }
 
 // ErrorDetail for API error responses
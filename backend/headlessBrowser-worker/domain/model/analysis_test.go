@@ -0,0 +1,15 @@
+package model
+
+import "testing"
+
+func TestPageLinks(t *testing.T) {
+	links := []LinkInfo{{Address: "a"}, {Address: "b"}, {Address: "c"}}
+
+	page := PageLinks(links, 1, 5)
+	if page.Total != 3 || len(page.Links) != 2 || page.Links[0].Address != "b" {
+		t.Errorf("unexpected page %+v", page)
+	}
+	if past := PageLinks(links, 10, 5); past.Links == nil || len(past.Links) != 0 || past.Total != 3 {
+		t.Errorf("expected an empty page past the end, got %+v", past)
+	}
+}
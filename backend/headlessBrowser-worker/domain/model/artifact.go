@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Artifact is a file produced while analyzing a job, such as a screenshot,
+// served by the worker under URL
+type Artifact struct {
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+	// ExpiresAt is when the worker deletes the file; unset if it is kept
+	// until removed by hand
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,43 @@
+package model
+
+// Console levels, from most to least severe
+const (
+	ConsoleError   = "error"
+	ConsoleWarning = "warning"
+	ConsoleInfo    = "info"
+	ConsoleLog     = "log"
+	ConsoleDebug   = "debug"
+)
+
+// ConsoleMessage is a console call, uncaught exception or browser log entry
+// seen while rendering
+type ConsoleMessage struct {
+	Level string `json:"level"`
+	// Source is "console", "exception" or the browser log source such as
+	// "network", "security" or "violation"
+	Source  string `json:"source"`
+	Message string `json:"message"`
+	URL     string `json:"url,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	// Stack is one "at function (url:line:column)" frame per line
+	Stack string `json:"stack,omitempty"`
+}
+
+// ConsoleEntry is a deduplicated ConsoleMessage with its number of
+// occurrences
+type ConsoleEntry struct {
+	ConsoleMessage
+	Count int `json:"count"`
+}
+
+// ConsoleReport is the console section of AnalysisResult
+type ConsoleReport struct {
+	// Counts holds the occurrences per level, duplicates included
+	Counts map[string]int `json:"counts"`
+	// Entries are ordered by level, most severe first, then by first
+	// occurrence
+	Entries []ConsoleEntry `json:"entries"`
+	// Truncated is set when entries beyond the limit were dropped
+	Truncated bool `json:"truncated,omitempty"`
+}
@@ -0,0 +1,57 @@
+package model
+
+// PageDiff lists what differs between two renders of the same page: a
+// baseline and a variant of it
+type PageDiff struct {
+	Identical bool       `json:"identical"`
+	Title     *TitleDiff `json:"title,omitempty"`
+	// Headings holds only the levels whose counts differ
+	Headings map[string]CountDiff `json:"headings,omitempty"`
+	// Links are resolved, deduplicated and sorted
+	LinksOnlyInBaseline []string `json:"links_only_in_baseline"`
+	LinksOnlyInVariant  []string `json:"links_only_in_variant"`
+	// Forms is set when the renders have a different number of forms
+	Forms *CountDiff `json:"forms,omitempty"`
+	// LoginFormOnlyIn is "baseline" or "variant" when only one render has
+	// a login form
+	LoginFormOnlyIn string `json:"login_form_only_in,omitempty"`
+}
+
+type TitleDiff struct {
+	Baseline string `json:"baseline"`
+	Variant  string `json:"variant"`
+}
+
+type CountDiff struct {
+	Baseline int `json:"baseline"`
+	Variant  int `json:"variant"`
+}
+
+// MobileComparison is the mobile render of a compare_mobile job. Its diff
+// uses the desktop render as the baseline.
+type MobileComparison struct {
+	Emulation     Emulation      `json:"emulation"`
+	PageTitle     string         `json:"page_title"`
+	HeadingCounts map[string]int `json:"heading_counts"`
+	Links         LinkStats      `json:"links"`
+	HasLoginForm  bool           `json:"has_login_form"`
+	Screenshot    *Artifact      `json:"screenshot,omitempty"`
+	Diff          *PageDiff      `json:"diff,omitempty"`
+	// Error is set instead of the fields above if the mobile render failed
+	Error *ErrorDetail `json:"error,omitempty"`
+}
+
+// StaticComparison is the page as fetched without JavaScript for a
+// compare_static job. Its diff uses the static HTML as the baseline and the
+// rendered page as the variant, so LinksOnlyInVariant are the links only
+// JavaScript adds.
+type StaticComparison struct {
+	PageTitle     string         `json:"page_title"`
+	HeadingCounts map[string]int `json:"heading_counts"`
+	LinkCount     int            `json:"link_count"`
+	FormCount     int            `json:"form_count"`
+	HasLoginForm  bool           `json:"has_login_form"`
+	Diff          *PageDiff      `json:"diff,omitempty"`
+	// Error is set instead of the fields above if the static fetch failed
+	Error *ErrorDetail `json:"error,omitempty"`
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultViewport is used when a request does not pick one; the tall
+// viewport lets most lazy-loaded content render without scrolling
+var DefaultViewport = Viewport{Width: 1920, Height: 5000}
+
+const maxViewportSide = 10000
+
+// MobileDevice is the preset used for the mobile render of a comparison
+var MobileDevice = Emulation{
+	Viewport:          &Viewport{Width: 390, Height: 844},
+	DeviceScaleFactor: 3,
+	Mobile:            true,
+	UserAgent:         "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+}
+
+// Emulation describes the device and locale a page is rendered as. Zero
+// fields keep the browser's defaults.
+type Emulation struct {
+	Viewport          *Viewport `json:"viewport,omitempty"`
+	DeviceScaleFactor float64   `json:"device_scale_factor,omitempty"`
+	// Mobile enables mobile layout and touch events
+	Mobile         bool         `json:"mobile,omitempty"`
+	UserAgent      string       `json:"user_agent,omitempty"`
+	AcceptLanguage string       `json:"accept_language,omitempty"`
+	Timezone       string       `json:"timezone,omitempty"`
+	Geolocation    *Geolocation `json:"geolocation,omitempty"`
+}
+
+type Viewport struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type Geolocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Accuracy in meters, defaults to 100
+	Accuracy float64 `json:"accuracy,omitempty"`
+}
+
+func (e *Emulation) Validate() error {
+	if v := e.Viewport; v != nil {
+		if v.Width <= 0 || v.Height <= 0 || v.Width > maxViewportSide || v.Height > maxViewportSide {
+			return fmt.Errorf("viewport must be between 1 and %d pixels per side", maxViewportSide)
+		}
+	}
+	if e.DeviceScaleFactor < 0 || e.DeviceScaleFactor > 4 {
+		return errors.New("device_scale_factor must be between 0 and 4")
+	}
+	if e.Timezone != "" {
+		// Chrome only takes IANA names; LoadLocation also knows "Local"
+		_, err := time.LoadLocation(e.Timezone)
+		if err != nil || e.Timezone == "Local" || e.Timezone != "UTC" && !strings.Contains(e.Timezone, "/") {
+			return fmt.Errorf("unknown timezone %q, expected an IANA name like Europe/Berlin or UTC", e.Timezone)
+		}
+	}
+	if g := e.Geolocation; g != nil {
+		if g.Latitude < -90 || g.Latitude > 90 || g.Longitude < -180 || g.Longitude > 180 || g.Accuracy < 0 {
+			return errors.New("geolocation is out of range")
+		}
+	}
+	return nil
+}
+
+// EmulationOrDefault returns the emulation with the default viewport and
+// scale filled in
+func (o RenderOptions) EmulationOrDefault() Emulation {
+	var e Emulation
+	if o.Emulation != nil {
+		e = *o.Emulation
+	}
+	if e.Viewport == nil {
+		viewport := DefaultViewport
+		e.Viewport = &viewport
+	}
+	if e.DeviceScaleFactor == 0 {
+		e.DeviceScaleFactor = 1
+	}
+	if e.Geolocation != nil && e.Geolocation.Accuracy == 0 {
+		geo := *e.Geolocation
+		geo.Accuracy = 100
+		e.Geolocation = &geo
+	}
+	return e
+}
+
+// MobileVariant returns the options for the mobile render of a comparison:
+// the device is replaced by MobileDevice while language, timezone and
+// location stay as requested. The PDF, HAR and static comparison are only
+// made from desktop.
+func (o RenderOptions) MobileVariant() RenderOptions {
+	mobile := MobileDevice
+	if o.Emulation != nil {
+		mobile.AcceptLanguage = o.Emulation.AcceptLanguage
+		mobile.Timezone = o.Emulation.Timezone
+		mobile.Geolocation = o.Emulation.Geolocation
+	}
+	o.Emulation = &mobile
+	o.CompareMobile = false
+	o.CompareStatic = false
+	o.PDF = nil
+	o.HAR = false
+	return o
+}
+
+// Locale is the primary language of AcceptLanguage, e.g. "de-DE" for
+// "de-DE,de;q=0.9"
+func (e Emulation) Locale() string {
+	locale, _, _ := strings.Cut(e.AcceptLanguage, ",")
+	locale, _, _ = strings.Cut(locale, ";")
+	return strings.TrimSpace(locale)
+}
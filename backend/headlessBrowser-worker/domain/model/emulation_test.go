@@ -0,0 +1,55 @@
+package model
+
+import "testing"
+
+func TestEmulation_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		emulation Emulation
+		wantErr   bool
+	}{
+		{"empty", Emulation{}, false},
+		{"full", Emulation{
+			Viewport: &Viewport{Width: 390, Height: 844}, DeviceScaleFactor: 3, Mobile: true,
+			AcceptLanguage: "de-DE,de;q=0.9", Timezone: "Europe/Berlin",
+			Geolocation: &Geolocation{Latitude: 52.52, Longitude: 13.4},
+		}, false},
+		{"zero width", Emulation{Viewport: &Viewport{Width: 0, Height: 800}}, true},
+		{"huge viewport", Emulation{Viewport: &Viewport{Width: 20000, Height: 800}}, true},
+		{"scale too large", Emulation{DeviceScaleFactor: 10}, true},
+		{"unknown timezone", Emulation{Timezone: "Mars/Olympus"}, true},
+		{"local timezone", Emulation{Timezone: "Local"}, true},
+		{"timezone abbreviation", Emulation{Timezone: "EST"}, true},
+		{"utc", Emulation{Timezone: "UTC"}, false},
+		{"latitude out of range", Emulation{Geolocation: &Geolocation{Latitude: 91}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.emulation.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmulation_Locale(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"fr":                      "fr",
+		"de-DE,de;q=0.9,en;q=0.8": "de-DE",
+		"en-GB;q=0.9":             "en-GB",
+	}
+	for acceptLanguage, want := range tests {
+		if got := (Emulation{AcceptLanguage: acceptLanguage}).Locale(); got != want {
+			t.Errorf("Locale(%q) = %q, want %q", acceptLanguage, got, want)
+		}
+	}
+}
+
+func TestRenderOptions_EmulationOrDefault(t *testing.T) {
+	e := RenderOptions{}.EmulationOrDefault()
+	if *e.Viewport != DefaultViewport || e.DeviceScaleFactor != 1 {
+		t.Errorf("unexpected defaults: %+v", e)
+	}
+}
@@ -0,0 +1,37 @@
+package model
+
+// EventType identifies what an Event reports about a job
+type EventType string
+
+const (
+	EventRenderStarted EventType = "render_started"
+	// EventRenderFinished carries the Readiness of the page
+	EventRenderFinished EventType = "render_finished"
+	EventParseDone      EventType = "parse_done"
+	EventLinkChecked    EventType = "link_checked"
+	// Terminal events carry the final AnalysisResult
+	EventResult    EventType = "result"
+	EventFailed    EventType = "failed"
+	EventCancelled EventType = "cancelled"
+)
+
+// Event is a single message published for a job while it is processed
+type Event struct {
+	JobID string      `json:"job_id"`
+	Type  EventType   `json:"type"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// ParseSummary is the payload of EventParseDone
+type ParseSummary struct {
+	PageTitle     string         `json:"page_title"`
+	HeadingCounts map[string]int `json:"heading_counts"`
+	LinkCount     int            `json:"link_count"`
+	HasLoginForm  bool           `json:"has_login_form"`
+}
+
+// LinkProgress is the payload of EventLinkChecked
+type LinkProgress struct {
+	Checked int `json:"checked"`
+	Total   int `json:"total"`
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// JobStatus is the lifecycle stage of an analysis job
+type JobStatus string
+
+const (
+	JobQueued        JobStatus = "queued"
+	JobRendering     JobStatus = "rendering"
+	JobCheckingLinks JobStatus = "checking-links"
+	JobDone          JobStatus = "done"
+	JobFailed        JobStatus = "failed"
+	JobCancelled     JobStatus = "cancelled"
+)
+
+// IsTerminal reports whether no further transitions are expected
+func (s JobStatus) IsTerminal() bool {
+	return s == JobDone || s == JobFailed || s == JobCancelled
+}
+
+// Job tracks a single analysis from submission to its result
+type Job struct {
+	ID         string          `json:"id"`
+	URL        string          `json:"url"`
+	Status     JobStatus       `json:"status"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Result     *AnalysisResult `json:"result,omitempty"`
+	Error      *ErrorDetail    `json:"error,omitempty"`
+	Options    RenderOptions   `json:"options"`
+	// Links are all checked links of the result, served page by page
+	Links []LinkInfo `json:"-"`
+	// Queue placement, filled in when a queued job is reported
+	QueuePosition int `json:"queue_position,omitempty"`
+	QueueDepth    int `json:"queue_depth,omitempty"`
+}
+
+// NewJob creates a queued job for targetURL with a fresh ID
+func NewJob(targetURL string) *Job {
+	return &Job{
+		ID:        NewJobID(),
+		URL:       targetURL,
+		Status:    JobQueued,
+		CreatedAt: time.Now(),
+	}
+}
+
+// maxJobIDLength bounds job IDs chosen by clients
+const maxJobIDLength = 64
+
+// ValidJobID tells whether a job ID chosen by a client is usable: 1 to 64
+// letters, digits, '-' or '_', such as a UUID
+func ValidJobID(id string) bool {
+	if id == "" || len(id) > maxJobIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// NewJobID returns a random identifier used to route results back to the
+// client that requested the analysis
+func NewJobID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
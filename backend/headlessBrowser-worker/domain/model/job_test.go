@@ -0,0 +1,23 @@
+package model
+
+import "testing"
+
+func TestValidJobID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"3f2b8c1e-9d4a-4e7b-a1c2-5d6e7f8a9b0c", true},
+		{NewJobID(), true},
+		{"job_1", true},
+		{"", false},
+		{"analysis.events.*", false},
+		{"../../etc", false},
+		{string(make([]byte, 65)), false},
+	}
+	for _, tt := range tests {
+		if got := ValidJobID(tt.id); got != tt.valid {
+			t.Errorf("ValidJobID(%q) = %v, want %v", tt.id, got, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package model
+
+import "time"
+
+// NetworkEntry is one request made by the page while it was rendered. A
+// redirect produces one entry per hop.
+type NetworkEntry struct {
+	URL          string    `json:"url"`
+	Method       string    `json:"method"`
+	ResourceType string    `json:"resource_type"`
+	Initiator    Initiator `json:"initiator"`
+	StartedAt    time.Time `json:"started_at"`
+
+	RequestHeaders []Header `json:"request_headers"`
+	HasPostData    bool     `json:"has_post_data"`
+
+	// Status is 0 if the request failed before a response arrived
+	Status          int      `json:"status"`
+	StatusText      string   `json:"status_text"`
+	Protocol        string   `json:"protocol"`
+	MimeType        string   `json:"mime_type"`
+	ResponseHeaders []Header `json:"response_headers"`
+	RedirectURL     string   `json:"redirect_url,omitempty"`
+	RemoteIP        string   `json:"remote_ip,omitempty"`
+	FromCache       bool     `json:"from_cache"`
+	// TransferSize is the bytes received on the wire, ContentSize the
+	// decoded body size
+	TransferSize int64 `json:"transfer_size"`
+	ContentSize  int64 `json:"content_size"`
+	// Error is the network error of a failed request, e.g. net::ERR_ABORTED
+	Error   string  `json:"error,omitempty"`
+	Timings Timings `json:"timings"`
+}
+
+// Initiator is what caused a request: the parser, a script, a preload...
+type Initiator struct {
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Timings are the phases of a request in milliseconds, -1 when a phase did
+// not apply (e.g. no DNS lookup on a reused connection). SSL is part of
+// Connect.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Total is the duration of the request, leaving out phases that did not
+// apply and SSL, which Connect already includes
+func (t Timings) Total() float64 {
+	total := 0.0
+	for _, phase := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if phase > 0 {
+			total += phase
+		}
+	}
+	return total
+}
+
+// PageTimings are the page events in milliseconds after navigation
+// started, -1 if the event never fired
+type PageTimings struct {
+	DOMContentLoaded float64 `json:"dom_content_loaded"`
+	Load             float64 `json:"load"`
+}
+
+// NetworkSummary condenses the requests of a render for AnalysisResult
+type NetworkSummary struct {
+	Requests      int            `json:"requests"`
+	Failed        int            `json:"failed"`
+	FromCache     int            `json:"from_cache"`
+	TransferBytes int64          `json:"transfer_bytes"`
+	ContentBytes  int64          `json:"content_bytes"`
+	ByStatus      map[string]int `json:"by_status"`
+	ByType        map[string]int `json:"by_type"`
+	Hosts         int            `json:"hosts"`
+	// SlowestURL took SlowestMS, the longest of all requests
+	SlowestURL  string      `json:"slowest_url,omitempty"`
+	SlowestMS   float64     `json:"slowest_ms"`
+	PageTimings PageTimings `json:"page_timings"`
+	HAR         *Artifact   `json:"har,omitempty"`
+}
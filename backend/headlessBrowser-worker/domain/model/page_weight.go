@@ -0,0 +1,40 @@
+package model
+
+// Resource groups of PageWeight.ByType
+const (
+	ResourceDocument   = "document"
+	ResourceScript     = "script"
+	ResourceStylesheet = "stylesheet"
+	ResourceImage      = "image"
+	ResourceFont       = "font"
+	ResourceMedia      = "media"
+	// ResourceXHR covers both XMLHttpRequest and fetch
+	ResourceXHR   = "xhr"
+	ResourceOther = "other"
+)
+
+// PageWeight breaks down the bytes a render downloaded. Transfer bytes are
+// what came over the wire, content bytes the decoded bodies.
+type PageWeight struct {
+	WeightBucket
+	ByType map[string]WeightBucket `json:"by_type"`
+	// FirstParty is everything served from the page's registrable domain
+	FirstParty WeightBucket `json:"first_party"`
+	ThirdParty WeightBucket `json:"third_party"`
+	// Largest are the biggest resources by transfer size
+	Largest []ResourceWeight `json:"largest"`
+}
+
+type WeightBucket struct {
+	Requests      int   `json:"requests"`
+	TransferBytes int64 `json:"transfer_bytes"`
+	ContentBytes  int64 `json:"content_bytes"`
+}
+
+type ResourceWeight struct {
+	URL           string `json:"url"`
+	Type          string `json:"type"`
+	ThirdParty    bool   `json:"third_party"`
+	TransferBytes int64  `json:"transfer_bytes"`
+	ContentBytes  int64  `json:"content_bytes"`
+}
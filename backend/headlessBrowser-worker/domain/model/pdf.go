@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PaperSizes are the named paper sizes accepted in PDFOptions, in inches
+var PaperSizes = map[string][2]float64{
+	"a3":     {11.69, 16.54},
+	"a4":     {8.27, 11.69},
+	"a5":     {5.83, 8.27},
+	"letter": {8.5, 11},
+	"legal":  {8.5, 14},
+}
+
+// PDFOptions asks for a print of the page through Chrome's print-to-PDF.
+// Sizes and margins are in inches.
+type PDFOptions struct {
+	// Paper is a key of PaperSizes (default "a4"); PaperWidth and
+	// PaperHeight override it
+	Paper           string     `json:"paper,omitempty"`
+	PaperWidth      float64    `json:"paper_width,omitempty"`
+	PaperHeight     float64    `json:"paper_height,omitempty"`
+	Landscape       bool       `json:"landscape,omitempty"`
+	Margin          *PDFMargin `json:"margin,omitempty"`
+	PrintBackground bool       `json:"print_background,omitempty"`
+	// Scale of the page rendering, 0.1-2 (default 1)
+	Scale float64 `json:"scale,omitempty"`
+	// Templates are HTML with Chrome's date, title, url, pageNumber and
+	// totalPages classes; setting either turns on header and footer
+	HeaderTemplate string `json:"header_template,omitempty"`
+	FooterTemplate string `json:"footer_template,omitempty"`
+	// PageRanges such as "1-5, 8"; empty prints all pages
+	PageRanges string `json:"page_ranges,omitempty"`
+}
+
+type PDFMargin struct {
+	Top    float64 `json:"top"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+	Right  float64 `json:"right"`
+}
+
+func (p *PDFOptions) Validate() error {
+	if _, ok := PaperSizes[strings.ToLower(p.Paper)]; p.Paper != "" && !ok {
+		return fmt.Errorf("unknown pdf paper %q", p.Paper)
+	}
+	if p.PaperWidth < 0 || p.PaperHeight < 0 {
+		return errors.New("pdf paper size must be positive")
+	}
+	if (p.PaperWidth == 0) != (p.PaperHeight == 0) {
+		return errors.New("pdf paper_width and paper_height must be set together")
+	}
+	if p.Scale != 0 && (p.Scale < 0.1 || p.Scale > 2) {
+		return errors.New("pdf scale must be between 0.1 and 2")
+	}
+	if m := p.Margin; m != nil && (m.Top < 0 || m.Bottom < 0 || m.Left < 0 || m.Right < 0) {
+		return errors.New("pdf margins must not be negative")
+	}
+	return nil
+}
+
+// PaperSize returns the width and height of the paper in inches
+func (p PDFOptions) PaperSize() (width, height float64) {
+	if p.PaperWidth > 0 {
+		return p.PaperWidth, p.PaperHeight
+	}
+	size, ok := PaperSizes[strings.ToLower(p.Paper)]
+	if !ok {
+		size = PaperSizes["a4"]
+	}
+	return size[0], size[1]
+}
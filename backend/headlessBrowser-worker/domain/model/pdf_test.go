@@ -0,0 +1,39 @@
+package model
+
+import "testing"
+
+func TestPDFOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pdf     PDFOptions
+		wantErr bool
+	}{
+		{"default", PDFOptions{}, false},
+		{"named paper", PDFOptions{Paper: "Letter", Landscape: true}, false},
+		{"custom paper", PDFOptions{PaperWidth: 5, PaperHeight: 7}, false},
+		{"unknown paper", PDFOptions{Paper: "b7"}, true},
+		{"width without height", PDFOptions{PaperWidth: 5}, true},
+		{"scale too small", PDFOptions{Scale: 0.05}, true},
+		{"negative margin", PDFOptions{Margin: &PDFMargin{Top: -1}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pdf.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPDFOptions_PaperSize(t *testing.T) {
+	if w, h := (PDFOptions{}).PaperSize(); w != 8.27 || h != 11.69 {
+		t.Errorf("expected A4 by default, got %vx%v", w, h)
+	}
+	if w, h := (PDFOptions{Paper: "LEGAL"}).PaperSize(); w != 8.5 || h != 14 {
+		t.Errorf("expected legal paper, got %vx%v", w, h)
+	}
+	if w, h := (PDFOptions{Paper: "a4", PaperWidth: 4, PaperHeight: 6}).PaperSize(); w != 4 || h != 6 {
+		t.Errorf("expected the explicit size to win, got %vx%v", w, h)
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+// Ratings of a Web Vital against its standard thresholds
+const (
+	RatingGood             = "good"
+	RatingNeedsImprovement = "needs-improvement"
+	RatingPoor             = "poor"
+)
+
+// VitalsSample is what the browser measured; nil means the metric was not
+// available, e.g. no LCP on a page without content
+type VitalsSample struct {
+	TTFB             *float64 `json:"ttfb"`
+	DOMContentLoaded *float64 `json:"dom_content_loaded"`
+	Load             *float64 `json:"load"`
+	FCP              *float64 `json:"fcp"`
+	LCP              *float64 `json:"lcp"`
+	CLS              *float64 `json:"cls"`
+	TBT              *float64 `json:"tbt"`
+}
+
+// Metric is a measured value with its rating. Times are in milliseconds,
+// CLS is unitless.
+type Metric struct {
+	Value  float64 `json:"value"`
+	Rating string  `json:"rating,omitempty"`
+}
+
+// Performance is the performance block of AnalysisResult. Metrics that
+// could not be measured are left out.
+type Performance struct {
+	TTFB             *Metric `json:"ttfb,omitempty"`
+	FCP              *Metric `json:"fcp,omitempty"`
+	LCP              *Metric `json:"lcp,omitempty"`
+	CLS              *Metric `json:"cls,omitempty"`
+	TBT              *Metric `json:"tbt,omitempty"`
+	DOMContentLoaded *Metric `json:"dom_content_loaded,omitempty"`
+	Load             *Metric `json:"load,omitempty"`
+}
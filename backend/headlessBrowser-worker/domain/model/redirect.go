@@ -0,0 +1,23 @@
+package model
+
+// How a redirect hop was left for the next URL
+const (
+	// RedirectHTTP is a 3xx response or a Refresh header
+	RedirectHTTP        = "http"
+	RedirectMetaRefresh = "meta_refresh"
+	// RedirectJS is a navigation started by a script, e.g. location.href
+	RedirectJS    = "js"
+	RedirectOther = "other"
+)
+
+// RedirectHop is a URL the main document passed through before the final
+// one
+type RedirectHop struct {
+	URL string `json:"url"`
+	// Status is the response of URL; for client-side redirects it is the
+	// status of the page that redirected
+	Status int `json:"status"`
+	// Location is the URL the hop redirected to, already resolved
+	Location string `json:"location"`
+	Type     string `json:"type"`
+}
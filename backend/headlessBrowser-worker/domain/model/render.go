@@ -0,0 +1,204 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WaitStrategy decides when a rendered page is ready to be captured
+type WaitStrategy string
+
+const (
+	// WaitNetworkIdle waits until no request has been in flight for IdleMS
+	WaitNetworkIdle WaitStrategy = "network_idle"
+	// WaitSelector waits until Selector matches a visible element
+	WaitSelector WaitStrategy = "selector"
+	// WaitExpression waits until the JavaScript Expression is truthy
+	WaitExpression       WaitStrategy = "expression"
+	WaitDOMContentLoaded WaitStrategy = "dom_content_loaded"
+	WaitLoad             WaitStrategy = "load"
+	// WaitDelay waits a fixed DelayMS after navigation
+	WaitDelay WaitStrategy = "delay"
+)
+
+// RenderMode picks how a page is fetched for analysis
+type RenderMode string
+
+const (
+	// RenderBrowser renders the page in Chrome
+	RenderBrowser RenderMode = "browser"
+	// RenderStatic fetches the HTML over plain HTTP without running scripts
+	RenderStatic RenderMode = "static"
+	// RenderAuto fetches over HTTP and falls back to Chrome when the HTML
+	// looks like an empty single-page app shell
+	RenderAuto RenderMode = "auto"
+)
+
+const (
+	defaultWaitTimeout = 15 * time.Second
+	maxWaitTimeout     = 60 * time.Second
+	defaultIdleTime    = 500 * time.Millisecond
+)
+
+// RenderOptions are the per-request knobs of the browser render
+type RenderOptions struct {
+	// Render defaults to RenderBrowser. Without a browser only the user
+	// agent and language of Emulation apply and Wait is ignored.
+	Render    RenderMode   `json:"render,omitempty"`
+	Wait      *WaitOptions `json:"wait,omitempty"`
+	Emulation *Emulation   `json:"emulation,omitempty"`
+	// Screenshot, if set, captures the page as it was analyzed
+	Screenshot *ScreenshotOptions `json:"screenshot,omitempty"`
+	// PDF, if set, prints the page to a PDF artifact
+	PDF *PDFOptions `json:"pdf,omitempty"`
+	// HAR stores the network activity of the render as a HAR artifact and
+	// summarizes it in the result
+	HAR bool `json:"har,omitempty"`
+	// CompareMobile renders the page a second time as MobileDevice and
+	// reports how the two renders differ
+	CompareMobile bool `json:"compare_mobile,omitempty"`
+	// CompareStatic also fetches the page without JavaScript and reports
+	// what the rendered page adds or removes
+	CompareStatic bool `json:"compare_static,omitempty"`
+	// IgnoreRobots checks links even where robots.txt disallows it
+	IgnoreRobots bool `json:"ignore_robots,omitempty"`
+}
+
+// WaitOptions configures the page readiness check. Only the field that
+// belongs to Strategy is used.
+type WaitOptions struct {
+	Strategy   WaitStrategy `json:"strategy"`
+	Selector   string       `json:"selector,omitempty"`
+	Expression string       `json:"expression,omitempty"`
+	IdleMS     int          `json:"idle_ms,omitempty"`
+	DelayMS    int          `json:"delay_ms,omitempty"`
+	// TimeoutMS bounds the whole wait; the page is captured as it is once
+	// it expires
+	TimeoutMS int `json:"timeout_ms,omitempty"`
+}
+
+// Readiness records how the render decided the page was ready
+type Readiness struct {
+	Strategy WaitStrategy `json:"strategy"`
+	// Condition describes the condition that was met, or the timeout
+	Condition string `json:"condition"`
+	TimedOut  bool   `json:"timed_out"`
+	WaitedMS  int64  `json:"waited_ms"`
+}
+
+// RenderedPage is what a BrowserProvider hands back for analysis
+type RenderedPage struct {
+	HTML string
+	// Mode is RenderBrowser or RenderStatic, whichever produced the page
+	Mode RenderMode
+	// FinalURL and Status are those of the main document after Redirects
+	FinalURL  string
+	Status    int
+	Redirects []RedirectHop
+	Readiness Readiness
+	// Screenshot is set when RenderOptions asked for one
+	Screenshot *Screenshot
+	PDF        []byte
+	// Network lists the requests made while rendering, in start order
+	Network     []NetworkEntry
+	PageTimings PageTimings
+	Console     []ConsoleMessage
+	Vitals      VitalsSample
+}
+
+// Validate checks the options of a request before it is queued
+func (o RenderOptions) Validate() error {
+	switch o.Render {
+	case "", RenderBrowser, RenderAuto:
+	case RenderStatic:
+		if o.NeedsBrowser() {
+			return errors.New("screenshot, pdf, compare_mobile and compare_static need render mode browser or auto")
+		}
+	default:
+		return fmt.Errorf("unknown render mode %q", o.Render)
+	}
+	if o.Wait != nil {
+		if err := o.Wait.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.Emulation != nil {
+		if err := o.Emulation.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.Screenshot != nil {
+		if err := o.Screenshot.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.PDF != nil {
+		return o.PDF.Validate()
+	}
+	return nil
+}
+
+// NeedsBrowser reports whether the options ask for something only Chrome
+// can produce
+func (o RenderOptions) NeedsBrowser() bool {
+	return o.Screenshot != nil || o.PDF != nil || o.CompareMobile || o.CompareStatic
+}
+
+// RenderModeOrDefault returns Render, or RenderBrowser if it is not set
+func (o RenderOptions) RenderModeOrDefault() RenderMode {
+	if o.Render == "" {
+		return RenderBrowser
+	}
+	return o.Render
+}
+
+func (w *WaitOptions) Validate() error {
+	if w.IdleMS < 0 || w.DelayMS < 0 || w.TimeoutMS < 0 {
+		return errors.New("wait durations must not be negative")
+	}
+	if time.Duration(w.TimeoutMS)*time.Millisecond > maxWaitTimeout {
+		return fmt.Errorf("wait timeout_ms must not exceed %d", maxWaitTimeout.Milliseconds())
+	}
+	switch w.Strategy {
+	case WaitNetworkIdle, WaitDOMContentLoaded, WaitLoad, "":
+	case WaitSelector:
+		if w.Selector == "" {
+			return errors.New("wait strategy selector needs a selector")
+		}
+	case WaitExpression:
+		if w.Expression == "" {
+			return errors.New("wait strategy expression needs an expression")
+		}
+	case WaitDelay:
+		if w.DelayMS == 0 {
+			return errors.New("wait strategy delay needs delay_ms")
+		}
+	default:
+		return fmt.Errorf("unknown wait strategy %q", w.Strategy)
+	}
+	return nil
+}
+
+// WaitOrDefault returns the wait options with defaults filled in; without
+// options the page is captured once the network has been idle for 500ms
+func (o RenderOptions) WaitOrDefault() WaitOptions {
+	var w WaitOptions
+	if o.Wait != nil {
+		w = *o.Wait
+	}
+	if w.Strategy == "" {
+		w.Strategy = WaitNetworkIdle
+	}
+	if w.Strategy == WaitNetworkIdle && w.IdleMS == 0 {
+		w.IdleMS = int(defaultIdleTime.Milliseconds())
+	}
+	if w.TimeoutMS == 0 {
+		w.TimeoutMS = int(defaultWaitTimeout.Milliseconds())
+	}
+	return w
+}
+
+func (w WaitOptions) Timeout() time.Duration {
+	return time.Duration(w.TimeoutMS) * time.Millisecond
+}
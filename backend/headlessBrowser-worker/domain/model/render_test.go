@@ -0,0 +1,63 @@
+package model
+
+import "testing"
+
+func TestWaitOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		wait    WaitOptions
+		wantErr bool
+	}{
+		{"default", WaitOptions{}, false},
+		{"selector", WaitOptions{Strategy: WaitSelector, Selector: "#app"}, false},
+		{"selector missing", WaitOptions{Strategy: WaitSelector}, true},
+		{"expression missing", WaitOptions{Strategy: WaitExpression}, true},
+		{"delay missing", WaitOptions{Strategy: WaitDelay}, true},
+		{"unknown strategy", WaitOptions{Strategy: "forever"}, true},
+		{"timeout too long", WaitOptions{Strategy: WaitLoad, TimeoutMS: 120000}, true},
+		{"negative idle", WaitOptions{Strategy: WaitNetworkIdle, IdleMS: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RenderOptions{Wait: &tt.wait}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderOptions_WaitOrDefault(t *testing.T) {
+	w := RenderOptions{}.WaitOrDefault()
+	if w.Strategy != WaitNetworkIdle || w.IdleMS != 500 || w.TimeoutMS != 15000 {
+		t.Errorf("unexpected defaults: %+v", w)
+	}
+
+	w = RenderOptions{Wait: &WaitOptions{Strategy: WaitSelector, Selector: "main", TimeoutMS: 3000}}.WaitOrDefault()
+	if w.Strategy != WaitSelector || w.IdleMS != 0 || w.TimeoutMS != 3000 {
+		t.Errorf("explicit options were changed: %+v", w)
+	}
+}
+
+func TestRenderOptions_ValidateRenderMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    RenderOptions
+		wantErr bool
+	}{
+		{"default", RenderOptions{}, false},
+		{"static", RenderOptions{Render: RenderStatic}, false},
+		{"auto with screenshot", RenderOptions{Render: RenderAuto, Screenshot: &ScreenshotOptions{}}, false},
+		{"static with screenshot", RenderOptions{Render: RenderStatic, Screenshot: &ScreenshotOptions{}}, true},
+		{"static with comparison", RenderOptions{Render: RenderStatic, CompareMobile: true}, true},
+		{"unknown", RenderOptions{Render: "lynx"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
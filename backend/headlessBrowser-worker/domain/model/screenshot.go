@@ -0,0 +1,72 @@
+package model
+
+import "errors"
+
+const (
+	ScreenshotPNG  = "png"
+	ScreenshotJPEG = "jpeg"
+
+	defaultJPEGQuality = 80
+)
+
+// ScreenshotOptions asks for a capture of the page right before its DOM is
+// read. Without Clip the whole page is captured unless ViewportOnly is set.
+type ScreenshotOptions struct {
+	// Format is "png" (default) or "jpeg"
+	Format string `json:"format,omitempty"`
+	// Quality applies to jpeg only, 1-100 (default 80)
+	Quality      int   `json:"quality,omitempty"`
+	ViewportOnly bool  `json:"viewport_only,omitempty"`
+	Clip         *Clip `json:"clip,omitempty"`
+}
+
+// Clip is a region of the page in CSS pixels
+type Clip struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Screenshot is the captured image of a RenderedPage
+type Screenshot struct {
+	Format string
+	Data   []byte
+}
+
+func (s *ScreenshotOptions) Validate() error {
+	switch s.Format {
+	case "", ScreenshotPNG, ScreenshotJPEG:
+	default:
+		return errors.New("screenshot format must be png or jpeg")
+	}
+	if s.Quality < 0 || s.Quality > 100 {
+		return errors.New("screenshot quality must be between 1 and 100")
+	}
+	if s.Quality != 0 && s.Format != ScreenshotJPEG {
+		return errors.New("screenshot quality applies to jpeg only")
+	}
+	if c := s.Clip; c != nil && (c.X < 0 || c.Y < 0 || c.Width <= 0 || c.Height <= 0) {
+		return errors.New("screenshot clip must have a positive size inside the page")
+	}
+	return nil
+}
+
+// WithDefaults fills in the format and the jpeg quality
+func (s ScreenshotOptions) WithDefaults() ScreenshotOptions {
+	if s.Format == "" {
+		s.Format = ScreenshotPNG
+	}
+	if s.Format == ScreenshotJPEG && s.Quality == 0 {
+		s.Quality = defaultJPEGQuality
+	}
+	return s
+}
+
+// Extension is the file extension of the format, without the dot
+func (s Screenshot) Extension() string {
+	if s.Format == ScreenshotJPEG {
+		return "jpg"
+	}
+	return "png"
+}
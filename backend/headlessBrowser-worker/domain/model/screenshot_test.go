@@ -0,0 +1,27 @@
+package model
+
+import "testing"
+
+func TestScreenshotOptions_Validate(t *testing.T) {
+	valid := []ScreenshotOptions{
+		{},
+		{Format: ScreenshotJPEG, Quality: 60},
+		{Clip: &Clip{Width: 800, Height: 600}},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", s, err)
+		}
+	}
+	invalid := []ScreenshotOptions{
+		{Format: "gif"},
+		{Format: ScreenshotPNG, Quality: 50},
+		{Format: ScreenshotJPEG, Quality: 101},
+		{Clip: &Clip{Width: 0, Height: 600}},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", s)
+		}
+	}
+}
@@ -1,8 +1,18 @@
 package service
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+
+	"headlessBrowser-worker/domain/model"
 )
 
 func TestParseHTML_Comprehensive(t *testing.T) {
@@ -101,3 +111,145 @@ func TestDetermineHTMLVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessLinks_ReportsProgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	var calls []int
+	discovered := []model.DiscoveredLink{{Href: "/a", Text: "A"}, {Href: "/b"}, {Href: "/a"}, {Href: "/missing", Element: "footer a"}}
+	links := NewLinkChecker(LinkCheckerConfig{}).ProcessLinks(context.Background(), srv.URL, discovered, false, func(checked, total int) {
+		if total != 3 {
+			t.Errorf("expected total of 3 unique links, got %d", total)
+		}
+		calls = append(calls, checked)
+	})
+
+	if len(links) != 3 {
+		t.Fatalf("expected 3 unique links, got %d", len(links))
+	}
+	if len(calls) != 3 || calls[2] != 3 {
+		t.Errorf("expected progress 1..3, got %v", calls)
+	}
+	if a := links[0]; a.Address != srv.URL+"/a" || a.Text != "A" || a.Occurrences != 2 || !a.Accessible || a.StatusCode != http.StatusOK {
+		t.Errorf("expected /a first with its anchor text and both occurrences, got %+v", a)
+	}
+	if missing := links[2]; missing.Accessible || missing.StatusCode != http.StatusNotFound || missing.ErrorClass != model.LinkErrorHTTPStatus || missing.Element != "footer a" {
+		t.Errorf("expected /missing last as an inaccessible 404, got %+v", missing)
+	}
+}
+
+func TestProcessLinks_ClassifiesErrors(t *testing.T) {
+	// Nothing listens on a closed server's port
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	links := NewLinkChecker(LinkCheckerConfig{}).ProcessLinks(context.Background(), srv.URL, []model.DiscoveredLink{{Href: "/"}, {Href: "mailto:shop@example.com"}}, false, nil)
+	if len(links) != 2 {
+		t.Fatalf("expected 2 links, got %d", len(links))
+	}
+	if links[0].ErrorClass != model.LinkErrorRefused || links[0].Error == "" {
+		t.Errorf("expected a refused connection, got %+v", links[0])
+	}
+	if links[1].ErrorClass != model.LinkErrorOther {
+		t.Errorf("expected an unsupported scheme to be other, got %+v", links[1])
+	}
+}
+
+func TestClassifyLinkError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&url.Error{Op: "Get", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, model.LinkErrorDNS},
+		{&url.Error{Op: "Get", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}, model.LinkErrorTLS},
+		{&url.Error{Op: "Get", Err: context.DeadlineExceeded}, model.LinkErrorTimeout},
+		{errors.New("EOF"), model.LinkErrorOther},
+	}
+	for _, tt := range tests {
+		if got := classifyLinkError(tt.err); got != tt.want {
+			t.Errorf("classifyLinkError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDiffPages(t *testing.T) {
+	baseline := &model.AnalysisResult{
+		PageTitle:       "Home",
+		HeadingCounts:   map[string]int{"h1": 1, "h2": 3},
+		DiscoveredLinks: []model.DiscoveredLink{{Href: "/about"}, {Href: "https://example.com/contact"}, {Href: "/shop"}},
+		HasLoginForm:    true,
+		FormCount:       1,
+	}
+	variant := &model.AnalysisResult{
+		PageTitle:       "Home | Mobile",
+		HeadingCounts:   map[string]int{"h1": 1},
+		DiscoveredLinks: []model.DiscoveredLink{{Href: "https://example.com/about"}, {Href: "/contact"}, {Href: "/app"}},
+	}
+
+	diff := DiffPages("https://example.com/", "https://example.com/", baseline, variant)
+
+	if diff.Identical {
+		t.Fatal("expected renders to differ")
+	}
+	if diff.Title == nil || diff.Title.Variant != "Home | Mobile" {
+		t.Errorf("expected title diff, got %+v", diff.Title)
+	}
+	if len(diff.Headings) != 1 || diff.Headings["h2"] != (model.CountDiff{Baseline: 3, Variant: 0}) {
+		t.Errorf("expected only h2 to differ, got %v", diff.Headings)
+	}
+	if len(diff.LinksOnlyInBaseline) != 1 || diff.LinksOnlyInBaseline[0] != "https://example.com/shop" {
+		t.Errorf("unexpected baseline-only links %v", diff.LinksOnlyInBaseline)
+	}
+	if len(diff.LinksOnlyInVariant) != 1 || diff.LinksOnlyInVariant[0] != "https://example.com/app" {
+		t.Errorf("unexpected variant-only links %v", diff.LinksOnlyInVariant)
+	}
+	if diff.Forms == nil || *diff.Forms != (model.CountDiff{Baseline: 1, Variant: 0}) {
+		t.Errorf("expected form counts to differ, got %+v", diff.Forms)
+	}
+	if diff.LoginFormOnlyIn != "baseline" {
+		t.Errorf("expected login form only in baseline, got %q", diff.LoginFormOnlyIn)
+	}
+
+	if same := DiffPages("https://example.com/", "https://example.com/", baseline, baseline); !same.Identical {
+		t.Errorf("expected a page to be identical to itself, got %+v", same)
+	}
+}
+
+func TestDiffPages_ResolvesEachRenderAgainstItsURL(t *testing.T) {
+	desktop := &model.AnalysisResult{DiscoveredLinks: []model.DiscoveredLink{{Href: "https://m.example.com/about"}}}
+	// The mobile render was redirected to m.example.com
+	mobile := &model.AnalysisResult{DiscoveredLinks: []model.DiscoveredLink{{Href: "/about"}}}
+
+	diff := DiffPages("https://www.example.com/", "https://m.example.com/", desktop, mobile)
+	if !diff.Identical {
+		t.Errorf("expected the relative link to resolve against the mobile URL, got %+v", diff)
+	}
+}
+
+func TestParseHTML_LinkSources(t *testing.T) {
+	res, err := ParseHTML(strings.NewReader(`<nav><a id="home" class="menu active big" href="/">  Home
+		page </a></nav><footer><a class="social" href="https://x.com/shop"><img alt="Our X profile"></a></footer><a href="/bare"></a>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []model.DiscoveredLink{
+		{Href: "/", Text: "Home page", Element: "nav a#home.menu.active"},
+		{Href: "https://x.com/shop", Text: "Our X profile", Element: "footer a.social"},
+		{Href: "/bare", Element: "a"},
+	}
+	if len(res.DiscoveredLinks) != len(want) {
+		t.Fatalf("expected %d links, got %+v", len(want), res.DiscoveredLinks)
+	}
+	for i := range want {
+		if res.DiscoveredLinks[i] != want[i] {
+			t.Errorf("link %d: expected %+v, got %+v", i, want[i], res.DiscoveredLinks[i])
+		}
+	}
+}
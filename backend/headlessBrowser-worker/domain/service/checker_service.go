@@ -1,8 +1,9 @@
 package service
 
 import (
-	"net/http"
+	"context"
 	"net/url"
+	"sort"
 
 	"headlessBrowser-worker/domain/model"
 
@@ -11,48 +12,89 @@ import (
 	"time"
 )
 
-func ProcessLinks(baseURL string, rawLinks []string) []model.LinkInfo {
-	// 1. DEDUPLICATION: Use a map to keep only unique URLs
-	uniqueMap := make(map[string]bool)
-	var uniqueLinks []string
-	for _, l := range rawLinks {
-		resolved := resolveURL(baseURL, l)
-		if resolved != "" && !uniqueMap[resolved] {
-			uniqueMap[resolved] = true
-			uniqueLinks = append(uniqueLinks, resolved)
+// ProcessLinks resolves, deduplicates and checks links, returning one
+// LinkInfo per distinct URL in the order the links appear in the page.
+// With respectRobots, links are requested as the bot user agent, and those
+// that robots.txt disallows or that its Crawl-delay would hold back past
+// CrawlDelayBudget are skipped instead of requested.
+// onChecked, if not nil, is called after each link with the number checked so
+// far and the total. Once ctx is cancelled no new checks are started and the
+// links checked so far are returned.
+func (c *LinkChecker) ProcessLinks(ctx context.Context, baseURL string, links []model.DiscoveredLink, respectRobots bool, onChecked func(checked, total int)) []model.LinkInfo {
+	// 1. DEDUPLICATION: Keep the first occurrence of each URL and count the rest
+	seen := make(map[string]int)
+	var uniqueLinks []model.LinkInfo
+	// perHost numbers the links of each host in page order, so a
+	// Crawl-delay skips the last ones; hosts is the host of each link
+	var perHost []int
+	var hosts []string
+	hostLinks := make(map[string]int)
+	for _, l := range links {
+		resolved := resolveURL(baseURL, l.Href)
+		if resolved == "" {
+			continue
 		}
+		if i, ok := seen[resolved]; ok {
+			uniqueLinks[i].Occurrences++
+			continue
+		}
+		seen[resolved] = len(uniqueLinks)
+		uniqueLinks = append(uniqueLinks, model.LinkInfo{Address: resolved, Text: l.Text, Element: l.Element, Occurrences: 1})
+		host := ""
+		if u, err := url.Parse(resolved); err == nil {
+			host = strings.ToLower(u.Host)
+		}
+		perHost = append(perHost, hostLinks[host])
+		hosts = append(hosts, host)
+		hostLinks[host]++
+	}
+	userAgent := c.cfg.UserAgent
+	// pacer applies the Crawl-delay of robots.txt to this job only
+	var pacer *crawlPacer
+	if respectRobots {
+		userAgent = c.cfg.BotUserAgent
+		pacer = newCrawlPacer()
 	}
 
 	var wg sync.WaitGroup
-	linksChan := make(chan model.LinkInfo, len(uniqueLinks))
+	linksChan := make(chan checkedLink, len(uniqueLinks))
 	semaphore := make(chan struct{}, 10) // Reduced to 10 to avoid 403/429 errors
 
 	targetParsed, _ := url.Parse(baseURL)
 
-	for _, linkAddr := range uniqueLinks {
+	for i, link := range uniqueLinks {
 		wg.Add(1)
-		go func(resolvedURL string) {
+		go func(order int, info model.LinkInfo) {
 			defer wg.Done()
 
 			// 2. EXTERNAL LOGIC FIX
-			isExt := false
-			linkParsed, err := url.Parse(resolvedURL)
+			linkParsed, err := url.Parse(info.Address)
 			if err == nil && linkParsed.Host != "" {
 				// Compare hosts. strings.Contains handles elakiri.com vs www.elakiri.com
-				isExt = !strings.Contains(linkParsed.Host, targetParsed.Host)
+				info.IsExternal = !strings.Contains(linkParsed.Host, targetParsed.Host)
 			}
 
 			// 3. CHECK ACCESSIBILITY
-			semaphore <- struct{}{}
-			accessible := checkLink(resolvedURL)
-			<-semaphore
-
-			linksChan <- model.LinkInfo{
-				Address:    resolvedURL,
-				IsExternal: isExt,
-				Accessible: accessible,
+			var pace func(context.Context) error
+			if respectRobots {
+				allowed, crawlDelay := c.robotsAllow(ctx, info.Address, semaphore)
+				switch {
+				case !allowed:
+					info.Skipped = model.LinkSkippedRobots
+				case time.Duration(perHost[order])*crawlDelay > c.cfg.CrawlDelayBudget:
+					info.Skipped = model.LinkSkippedCrawlDelay
+				}
+				pace = pacer.pace(hosts[order], crawlDelay)
 			}
-		}(linkAddr)
+			if info.Skipped == "" {
+				c.check(ctx, &info, semaphore, userAgent, pace)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			linksChan <- checkedLink{order: order, info: info}
+		}(i, link)
 	}
 
 	go func() {
@@ -60,25 +102,26 @@ func ProcessLinks(baseURL string, rawLinks []string) []model.LinkInfo {
 		close(linksChan)
 	}()
 
-	var results []model.LinkInfo
+	var checked []checkedLink
 	for l := range linksChan {
-		results = append(results, l)
+		checked = append(checked, l)
+		if onChecked != nil {
+			onChecked(len(checked), len(uniqueLinks))
+		}
+	}
+	sort.Slice(checked, func(i, j int) bool { return checked[i].order < checked[j].order })
+
+	results := make([]model.LinkInfo, len(checked))
+	for i, l := range checked {
+		results[i] = l.info
 	}
 	return results
 }
 
-func checkLink(link string) bool {
-	client := &http.Client{Timeout: 5 * time.Second}
-	req, _ := http.NewRequest("GET", link, nil)
-	// IMPORTANT: Set User-Agent to prevent the "Inaccessible" 403 errors
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0.0.0")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-	return resp.StatusCode < 400
+// checkedLink is a checked link with its position among the page's links
+type checkedLink struct {
+	order int
+	info  model.LinkInfo
 }
 
 func resolveURL(base, link string) string {
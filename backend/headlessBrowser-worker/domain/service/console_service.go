@@ -0,0 +1,56 @@
+package service
+
+import (
+	"sort"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+// maxConsoleEntries bounds the console section of pages that log in a loop
+const maxConsoleEntries = 100
+
+var consoleSeverity = map[string]int{
+	model.ConsoleError:   0,
+	model.ConsoleWarning: 1,
+	model.ConsoleInfo:    2,
+	model.ConsoleLog:     3,
+	model.ConsoleDebug:   4,
+}
+
+// SummarizeConsole deduplicates messages by level, text and location and
+// counts them per level
+func SummarizeConsole(messages []model.ConsoleMessage) model.ConsoleReport {
+	report := model.ConsoleReport{Counts: make(map[string]int), Entries: []model.ConsoleEntry{}}
+
+	type key struct {
+		level, message, url string
+		line, column        int
+	}
+	index := make(map[key]int)
+	for _, m := range messages {
+		report.Counts[m.Level]++
+		k := key{m.Level, m.Message, m.URL, m.Line, m.Column}
+		if i, ok := index[k]; ok {
+			report.Entries[i].Count++
+			continue
+		}
+		index[k] = len(report.Entries)
+		report.Entries = append(report.Entries, model.ConsoleEntry{ConsoleMessage: m, Count: 1})
+	}
+
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		return severity(report.Entries[i].Level) < severity(report.Entries[j].Level)
+	})
+	if len(report.Entries) > maxConsoleEntries {
+		report.Entries = report.Entries[:maxConsoleEntries]
+		report.Truncated = true
+	}
+	return report
+}
+
+func severity(level string) int {
+	if s, ok := consoleSeverity[level]; ok {
+		return s
+	}
+	return len(consoleSeverity)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+func TestSummarizeConsole(t *testing.T) {
+	boom := model.ConsoleMessage{Level: model.ConsoleError, Source: "exception", Message: "TypeError: x is undefined", URL: "https://example.com/app.js", Line: 3}
+	messages := []model.ConsoleMessage{
+		{Level: model.ConsoleLog, Source: "console", Message: "booting"},
+		boom,
+		{Level: model.ConsoleWarning, Source: "console", Message: "deprecated API"},
+		boom,
+		// Same text from another line is a different problem
+		{Level: model.ConsoleError, Source: "exception", Message: "TypeError: x is undefined", URL: "https://example.com/app.js", Line: 9},
+	}
+
+	report := SummarizeConsole(messages)
+
+	if report.Counts[model.ConsoleError] != 3 || report.Counts[model.ConsoleWarning] != 1 || report.Counts[model.ConsoleLog] != 1 {
+		t.Errorf("unexpected counts %v", report.Counts)
+	}
+	if len(report.Entries) != 4 {
+		t.Fatalf("expected 4 distinct entries, got %d", len(report.Entries))
+	}
+	if first := report.Entries[0]; first.Line != 3 || first.Count != 2 {
+		t.Errorf("expected the repeated error first with count 2, got %+v", first)
+	}
+	if report.Entries[2].Level != model.ConsoleWarning || report.Entries[3].Level != model.ConsoleLog {
+		t.Errorf("expected entries ordered by severity, got %+v", report.Entries)
+	}
+}
+
+func TestSummarizeConsole_Truncates(t *testing.T) {
+	var messages []model.ConsoleMessage
+	for i := 0; i < maxConsoleEntries+5; i++ {
+		messages = append(messages, model.ConsoleMessage{Level: model.ConsoleLog, Message: fmt.Sprint("tick ", i)})
+	}
+	report := SummarizeConsole(messages)
+	if len(report.Entries) != maxConsoleEntries || !report.Truncated || report.Counts[model.ConsoleLog] != maxConsoleEntries+5 {
+		t.Errorf("expected %d entries and a full count, got %d entries, %v", maxConsoleEntries, len(report.Entries), report.Counts)
+	}
+}
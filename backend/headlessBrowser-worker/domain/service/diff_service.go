@@ -0,0 +1,70 @@
+package service
+
+import (
+	"sort"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+// DiffPages compares two parsed renders of the same page. Links are
+// compared after resolving them against the URL each render ended up on, so
+// "/a" and an absolute link to the same page count as one.
+func DiffPages(baselineURL, variantURL string, baseline, variant *model.AnalysisResult) model.PageDiff {
+	diff := model.PageDiff{}
+
+	if baseline.PageTitle != variant.PageTitle {
+		diff.Title = &model.TitleDiff{Baseline: baseline.PageTitle, Variant: variant.PageTitle}
+	}
+
+	for _, level := range []string{"h1", "h2", "h3", "h4", "h5", "h6"} {
+		b, v := baseline.HeadingCounts[level], variant.HeadingCounts[level]
+		if b != v {
+			if diff.Headings == nil {
+				diff.Headings = make(map[string]model.CountDiff)
+			}
+			diff.Headings[level] = model.CountDiff{Baseline: b, Variant: v}
+		}
+	}
+
+	baselineLinks := linkSet(baselineURL, baseline.DiscoveredLinks)
+	variantLinks := linkSet(variantURL, variant.DiscoveredLinks)
+	diff.LinksOnlyInBaseline = missingFrom(baselineLinks, variantLinks)
+	diff.LinksOnlyInVariant = missingFrom(variantLinks, baselineLinks)
+
+	switch {
+	case baseline.HasLoginForm && !variant.HasLoginForm:
+		diff.LoginFormOnlyIn = "baseline"
+	case variant.HasLoginForm && !baseline.HasLoginForm:
+		diff.LoginFormOnlyIn = "variant"
+	}
+
+	if baseline.FormCount != variant.FormCount {
+		diff.Forms = &model.CountDiff{Baseline: baseline.FormCount, Variant: variant.FormCount}
+	}
+
+	diff.Identical = diff.Title == nil && diff.Headings == nil && diff.Forms == nil && diff.LoginFormOnlyIn == "" &&
+		len(diff.LinksOnlyInBaseline) == 0 && len(diff.LinksOnlyInVariant) == 0
+	return diff
+}
+
+func linkSet(baseURL string, links []model.DiscoveredLink) map[string]bool {
+	set := make(map[string]bool, len(links))
+	for _, l := range links {
+		if resolved := resolveURL(baseURL, l.Href); resolved != "" {
+			set[resolved] = true
+		}
+	}
+	return set
+}
+
+// missingFrom returns the sorted members of a that are not in b
+func missingFrom(a, b map[string]bool) []string {
+	missing := []string{}
+	for link := range a {
+		if !b[link] {
+			missing = append(missing, link)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
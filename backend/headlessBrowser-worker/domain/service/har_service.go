@@ -0,0 +1,162 @@
+package service
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+const harPageID = "page_1"
+
+// BuildHAR turns the requests of one render into a HAR 1.2 document
+func BuildHAR(pageURL, title string, entries []model.NetworkEntry, timings model.PageTimings) model.HAR {
+	started := time.Now()
+	if len(entries) > 0 {
+		started = entries[0].StartedAt
+	}
+	if title == "" {
+		title = pageURL
+	}
+
+	har := model.HAR{Log: model.HARLog{
+		Version: "1.2",
+		Creator: model.HARCreator{Name: "snappy-analyzer", Version: "1.0"},
+		Pages: []model.HARPage{{
+			StartedDateTime: started.Format(time.RFC3339Nano),
+			ID:              harPageID,
+			Title:           title,
+			PageTimings:     model.HARPageTimings{OnContentLoad: timings.DOMContentLoaded, OnLoad: timings.Load},
+		}},
+		Entries: make([]model.HAREntry, 0, len(entries)),
+	}}
+
+	for _, e := range entries {
+		version := httpVersion(e.Protocol)
+		har.Log.Entries = append(har.Log.Entries, model.HAREntry{
+			PageRef:         harPageID,
+			StartedDateTime: e.StartedAt.Format(time.RFC3339Nano),
+			Time:            e.Timings.Total(),
+			Request: model.HARRequest{
+				Method:      e.Method,
+				URL:         e.URL,
+				HTTPVersion: version,
+				Cookies:     []model.HARNameValue{},
+				Headers:     harHeaders(e.RequestHeaders),
+				QueryString: queryString(e.URL),
+				HeadersSize: -1,
+				BodySize:    requestBodySize(e),
+			},
+			Response: model.HARResponse{
+				Status:       e.Status,
+				StatusText:   e.StatusText,
+				HTTPVersion:  version,
+				Cookies:      []model.HARNameValue{},
+				Headers:      harHeaders(e.ResponseHeaders),
+				Content:      model.HARContent{Size: e.ContentSize, MimeType: e.MimeType},
+				RedirectURL:  e.RedirectURL,
+				HeadersSize:  -1,
+				BodySize:     -1,
+				TransferSize: e.TransferSize,
+			},
+			Timings:         e.Timings,
+			ServerIPAddress: e.RemoteIP,
+			Initiator:       e.Initiator,
+			ResourceType:    e.ResourceType,
+			FromCache:       e.FromCache,
+			Error:           e.Error,
+		})
+	}
+	return har
+}
+
+// SummarizeNetwork counts the requests of a render by outcome and type
+func SummarizeNetwork(entries []model.NetworkEntry, timings model.PageTimings) model.NetworkSummary {
+	summary := model.NetworkSummary{
+		Requests:    len(entries),
+		ByStatus:    make(map[string]int),
+		ByType:      make(map[string]int),
+		PageTimings: timings,
+	}
+	hosts := make(map[string]bool)
+	for _, e := range entries {
+		switch {
+		case e.Error != "" || e.Status == 0:
+			summary.Failed++
+			summary.ByStatus["failed"]++
+		default:
+			summary.ByStatus[statusClass(e.Status)]++
+		}
+		if e.FromCache {
+			summary.FromCache++
+		}
+		summary.ByType[e.ResourceType]++
+		summary.TransferBytes += e.TransferSize
+		summary.ContentBytes += e.ContentSize
+		if u, err := url.Parse(e.URL); err == nil && u.Host != "" {
+			hosts[u.Host] = true
+		}
+		if total := e.Timings.Total(); total > summary.SlowestMS {
+			summary.SlowestMS = total
+			summary.SlowestURL = e.URL
+		}
+	}
+	summary.Hosts = len(hosts)
+	return summary
+}
+
+// httpVersion maps Chrome's ALPN protocol names to HAR versions
+func httpVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "h2":
+		return "HTTP/2.0"
+	case "h3", "h3-29":
+		return "HTTP/3"
+	case "http/1.0":
+		return "HTTP/1.0"
+	case "", "http/1.1":
+		return "HTTP/1.1"
+	}
+	return protocol
+}
+
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
+
+func harHeaders(headers []model.Header) []model.HARNameValue {
+	pairs := make([]model.HARNameValue, 0, len(headers))
+	for _, h := range headers {
+		pairs = append(pairs, model.HARNameValue{Name: h.Name, Value: h.Value})
+	}
+	return pairs
+}
+
+func queryString(rawURL string) []model.HARNameValue {
+	pairs := []model.HARNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+	for _, part := range strings.Split(u.RawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		name, _ = url.QueryUnescape(name)
+		value, _ = url.QueryUnescape(value)
+		pairs = append(pairs, model.HARNameValue{Name: name, Value: value})
+	}
+	return pairs
+}
+
+// requestBodySize is unknown (-1) for requests with a body since Chrome
+// does not report its size
+func requestBodySize(e model.NetworkEntry) int64 {
+	if e.HasPostData {
+		return -1
+	}
+	return 0
+}
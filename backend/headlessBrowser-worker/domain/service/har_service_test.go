@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+func testNetworkEntries() []model.NetworkEntry {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []model.NetworkEntry{
+		{
+			URL: "http://example.com/", Method: "GET", ResourceType: "Document", StartedAt: start,
+			Status: 301, StatusText: "Moved Permanently", Protocol: "http/1.1", RedirectURL: "https://example.com/",
+			Timings: model.Timings{Blocked: -1, DNS: 10, Connect: 20, SSL: -1, Send: 1, Wait: 30, Receive: 0},
+		},
+		{
+			URL: "https://example.com/", Method: "GET", ResourceType: "Document", StartedAt: start.Add(time.Second),
+			Status: 200, StatusText: "OK", Protocol: "h2", MimeType: "text/html", TransferSize: 1200, ContentSize: 5000,
+			ResponseHeaders: []model.Header{{Name: "content-type", Value: "text/html"}},
+			Timings:         model.Timings{Blocked: 2, DNS: -1, Connect: 40, SSL: 25, Send: 1, Wait: 100, Receive: 7},
+		},
+		{
+			URL: "https://cdn.example.net/app.js?v=2&x=a%20b", Method: "GET", ResourceType: "Script", StartedAt: start.Add(2 * time.Second),
+			Initiator: model.Initiator{Type: "parser", URL: "https://example.com/", Line: 12},
+			Error:     "net::ERR_NAME_NOT_RESOLVED", Timings: model.Timings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: 5},
+		},
+	}
+}
+
+func TestBuildHAR(t *testing.T) {
+	timings := model.PageTimings{DOMContentLoaded: 800, Load: 1500}
+	har := BuildHAR("http://example.com/", "Example", testNetworkEntries(), timings)
+
+	if har.Log.Version != "1.2" || len(har.Log.Pages) != 1 || len(har.Log.Entries) != 3 {
+		t.Fatalf("unexpected HAR shape: %+v", har.Log)
+	}
+	if har.Log.Pages[0].PageTimings.OnLoad != 1500 {
+		t.Errorf("expected onLoad 1500, got %v", har.Log.Pages[0].PageTimings.OnLoad)
+	}
+
+	redirect, doc, script := har.Log.Entries[0], har.Log.Entries[1], har.Log.Entries[2]
+	if redirect.Response.RedirectURL != "https://example.com/" || redirect.Time != 61 {
+		t.Errorf("unexpected redirect entry %+v", redirect)
+	}
+	if doc.Response.HTTPVersion != "HTTP/2.0" || doc.Time != 150 || doc.Response.Content.Size != 5000 {
+		t.Errorf("unexpected document entry: version %q, time %v", doc.Response.HTTPVersion, doc.Time)
+	}
+	if len(script.Request.QueryString) != 2 || script.Request.QueryString[1].Value != "a b" {
+		t.Errorf("unexpected query string %v", script.Request.QueryString)
+	}
+	if script.Error == "" || script.Initiator.Type != "parser" {
+		t.Errorf("expected the failed script to keep its error and initiator, got %+v", script)
+	}
+
+	// Every HAR reader expects these arrays, even when empty
+	data, _ := json.Marshal(har)
+	var decoded map[string]interface{}
+	json.Unmarshal(data, &decoded)
+	entry := decoded["log"].(map[string]interface{})["entries"].([]interface{})[0].(map[string]interface{})
+	if entry["request"].(map[string]interface{})["cookies"] == nil {
+		t.Error("expected request.cookies to be an empty array, not null")
+	}
+}
+
+func TestSummarizeNetwork(t *testing.T) {
+	summary := SummarizeNetwork(testNetworkEntries(), model.PageTimings{})
+
+	if summary.Requests != 3 || summary.Failed != 1 {
+		t.Errorf("expected 3 requests with 1 failure, got %d/%d", summary.Requests, summary.Failed)
+	}
+	if summary.ByStatus["3xx"] != 1 || summary.ByStatus["2xx"] != 1 || summary.ByStatus["failed"] != 1 {
+		t.Errorf("unexpected status counts %v", summary.ByStatus)
+	}
+	if summary.ByType["Document"] != 2 || summary.Hosts != 2 {
+		t.Errorf("unexpected types %v or hosts %d", summary.ByType, summary.Hosts)
+	}
+	if summary.SlowestURL != "https://example.com/" || summary.TransferBytes != 1200 {
+		t.Errorf("unexpected slowest %q or transfer %d", summary.SlowestURL, summary.TransferBytes)
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// maxIdleHosts is how many hosts are remembered before idle ones are
+	// forgotten
+	maxIdleHosts = 1000
+	hostIdleTime = time.Minute
+	// maxHostBlock caps how long a Retry-After holds back a host for all
+	// jobs
+	maxHostBlock = 5 * time.Minute
+)
+
+// hostLimiter keeps the link checks to each host within a number of
+// parallel requests and a request rate, and holds them back while a host
+// asked to retry later. It is shared by all jobs.
+type hostLimiter struct {
+	mu          sync.Mutex
+	hosts       map[string]*hostState
+	concurrency int
+	rps         float64
+}
+
+type hostState struct {
+	slots chan struct{}
+	rate  *rate.Limiter
+	// blockedUntil is set from a Retry-After answer
+	blockedUntil time.Time
+	// users is the number of checks waiting for or holding a slot
+	users    int
+	lastUsed time.Time
+}
+
+func newHostLimiter(concurrency int, rps float64) *hostLimiter {
+	return &hostLimiter{hosts: make(map[string]*hostState), concurrency: concurrency, rps: rps}
+}
+
+// acquire waits until a request to host may be sent. The returned release
+// must be called once the request is done.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	l.mu.Lock()
+	st := l.state(host)
+	st.users++
+	l.mu.Unlock()
+
+	leave := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		st.users--
+		st.lastUsed = time.Now()
+		l.prune()
+	}
+
+	select {
+	case st.slots <- struct{}{}:
+	case <-ctx.Done():
+		leave()
+		return nil, ctx.Err()
+	}
+	release := func() {
+		<-st.slots
+		leave()
+	}
+
+	l.mu.Lock()
+	until := st.blockedUntil
+	l.mu.Unlock()
+	if err := sleepCtx(ctx, time.Until(until)); err != nil {
+		release()
+		return nil, err
+	}
+	if err := st.rate.Wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// state returns the state of host, creating it if needed; l.mu must be held
+func (l *hostLimiter) state(host string) *hostState {
+	st, ok := l.hosts[host]
+	if !ok {
+		st = &hostState{slots: make(chan struct{}, l.concurrency), rate: rate.NewLimiter(rate.Limit(l.rps), 1)}
+		l.hosts[host] = st
+	}
+	return st
+}
+
+// block holds back requests to host until the given time, at most
+// maxHostBlock from now
+func (l *hostLimiter) block(host string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until = minTime(until, time.Now().Add(maxHostBlock))
+	if st := l.state(host); until.After(st.blockedUntil) {
+		st.blockedUntil = until
+	}
+}
+
+// prune forgets hosts that have not been used for a while once there are
+// many of them; l.mu must be held
+func (l *hostLimiter) prune() {
+	if len(l.hosts) <= maxIdleHosts {
+		return
+	}
+	for host, st := range l.hosts {
+		if st.users == 0 && time.Since(st.lastUsed) > hostIdleTime && time.Now().After(st.blockedUntil) {
+			delete(l.hosts, host)
+		}
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// sleepCtx waits for d, returning early with the error of ctx
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+// Redirect policies of LinkCheckerConfig
+const (
+	// RedirectFollow follows up to MaxRedirects redirects
+	RedirectFollow = "follow"
+	// RedirectSameHost follows redirects only while they stay on the host
+	// of the link
+	RedirectSameHost = "same_host"
+	// RedirectNone reports the first response, even if it is a redirect
+	RedirectNone = "none"
+)
+
+// botInfoURL explains the bot to site owners in BotUserAgent
+const botInfoURL = "https://github.com/UpulWaruna/snappy-analyzer"
+
+// maxDrainBytes is how much of an unwanted body is read so the connection
+// can be reused; larger bodies are cut off by closing the connection
+const maxDrainBytes = 4 << 10
+
+type LinkCheckerConfig struct {
+	// Timeout bounds each attempt, including a GET after a rejected HEAD
+	Timeout        time.Duration
+	MaxRedirects   int
+	RedirectPolicy string
+	// UserAgent is sent when robots.txt is ignored
+	UserAgent string
+	// RobotsAgent is the product token matched against the User-agent
+	// lines of robots.txt. BotUserAgent names it and is sent for robots.txt
+	// and every link checked under its rules, so sites can tell who we are.
+	RobotsAgent  string
+	BotUserAgent string
+	// CrawlDelayBudget is how long a Crawl-delay may hold back the links
+	// of one host in a job; links past it are skipped
+	CrawlDelayBudget time.Duration
+	// PerHostConcurrency and PerHostRPS limit the checks to each host
+	// across all jobs
+	PerHostConcurrency int
+	PerHostRPS         float64
+	// MaxAttempts is how often a check is tried when it fails with a
+	// timeout, a dropped connection, 429, 502, 503 or 504
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	// MaxRetryDelay is the longest wait before a retry; a longer
+	// Retry-After gives up on the link instead
+	MaxRetryDelay time.Duration
+}
+
+// LinkChecker checks links with one pooled HTTP client. It asks with HEAD
+// and only falls back to a GET for the first byte when a server rejects
+// HEAD, so link targets are never downloaded.
+type LinkChecker struct {
+	client *http.Client
+	hosts  *hostLimiter
+	robots *robotsCache
+	cfg    LinkCheckerConfig
+}
+
+// NewLinkChecker fills in defaults for the zero fields of cfg; an unknown
+// redirect policy means RedirectFollow
+func NewLinkChecker(cfg LinkCheckerConfig) *LinkChecker {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = 10
+	}
+	if cfg.RedirectPolicy != RedirectSameHost && cfg.RedirectPolicy != RedirectNone {
+		cfg.RedirectPolicy = RedirectFollow
+	}
+	if cfg.PerHostConcurrency <= 0 {
+		cfg.PerHostConcurrency = 2
+	}
+	if cfg.PerHostRPS <= 0 {
+		cfg.PerHostRPS = 5
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.RetryBaseDelay <= 0 {
+		cfg.RetryBaseDelay = 500 * time.Millisecond
+	}
+	if cfg.MaxRetryDelay <= 0 {
+		cfg.MaxRetryDelay = 30 * time.Second
+	}
+	if cfg.RobotsAgent == "" {
+		cfg.RobotsAgent = "snappy-analyzer"
+	}
+	if cfg.BotUserAgent == "" {
+		cfg.BotUserAgent = fmt.Sprintf("Mozilla/5.0 (compatible; %s/1.0; +%s)", cfg.RobotsAgent, botInfoURL)
+	}
+	if cfg.CrawlDelayBudget <= 0 {
+		cfg.CrawlDelayBudget = time.Minute
+	}
+	if cfg.UserAgent == "" {
+		// IMPORTANT: a browser User-Agent prevents the "Inaccessible" 403 errors
+		cfg.UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0.0.0"
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = cfg.PerHostConcurrency
+	transport.IdleConnTimeout = 30 * time.Second
+	transport.TLSHandshakeTimeout = cfg.Timeout
+	transport.ResponseHeaderTimeout = cfg.Timeout
+
+	c := &LinkChecker{cfg: cfg, hosts: newHostLimiter(cfg.PerHostConcurrency, cfg.PerHostRPS), robots: newRobotsCache()}
+	c.client = &http.Client{Transport: transport, CheckRedirect: c.checkRedirect}
+	return c
+}
+
+func (c *LinkChecker) checkRedirect(req *http.Request, via []*http.Request) error {
+	switch {
+	case c.cfg.RedirectPolicy == RedirectNone:
+		return http.ErrUseLastResponse
+	case c.cfg.RedirectPolicy == RedirectSameHost && req.URL.Host != via[0].URL.Host:
+		return http.ErrUseLastResponse
+	case len(via) >= c.cfg.MaxRedirects:
+		return fmt.Errorf("stopped after %d redirects", c.cfg.MaxRedirects)
+	}
+	return nil
+}
+
+// check requests info.Address as userAgent and records the outcome in info.
+// Each attempt waits for pace, if set, then takes a slot of the host and
+// one of slots, the concurrency limit of the job. Transient failures are
+// retried with backoff.
+func (c *LinkChecker) check(ctx context.Context, info *model.LinkInfo, slots chan struct{}, userAgent string, pace func(context.Context) error) {
+	host := ""
+	if u, err := url.Parse(info.Address); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, elapsed, err := c.attempt(ctx, host, info.Address, slots, userAgent, pace)
+		if ctx.Err() != nil {
+			return
+		}
+		delay, retryAfter, transient := c.backoff(resp, err, attempt)
+		if retryAfter && host != "" {
+			// The host asked every client to hold back, not just this check,
+			// even when this link gives up
+			c.hosts.block(host, time.Now().Add(delay))
+		}
+		if !transient || attempt >= c.cfg.MaxAttempts || delay > c.cfg.MaxRetryDelay {
+			info.ResponseMS = elapsed.Milliseconds()
+			record(info, resp, err)
+			return
+		}
+		info.Retries++
+		if sleepCtx(ctx, delay) != nil {
+			return
+		}
+	}
+}
+
+// attempt sends one check of link and returns its response with the body
+// already drained and closed
+func (c *LinkChecker) attempt(ctx context.Context, host, link string, slots chan struct{}, userAgent string, pace func(context.Context) error) (*http.Response, time.Duration, error) {
+	if pace != nil {
+		if err := pace(ctx); err != nil {
+			return nil, 0, err
+		}
+	}
+	if host != "" {
+		release, err := c.hosts.acquire(ctx, host)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer release()
+	}
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
+	defer func() { <-slots }()
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := c.do(ctx, http.MethodHead, link, userAgent)
+	if headRejected(resp, err) {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		resp, err = c.do(ctx, http.MethodGet, link, userAgent)
+	}
+	if err != nil {
+		return nil, time.Since(start), err
+	}
+	drain(resp)
+	return resp, time.Since(start), nil
+}
+
+// backoff tells whether a failed attempt is worth retrying and after how
+// long: as long as the server's Retry-After asks for, or exponentially
+// longer per attempt with jitter so checks of one host do not retry in step
+func (c *LinkChecker) backoff(resp *http.Response, err error, attempt int) (delay time.Duration, retryAfter, transient bool) {
+	switch {
+	case err != nil:
+		transient = classifyLinkError(err) == model.LinkErrorTimeout || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		transient = true
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return d, true, true
+		}
+	case resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusGatewayTimeout:
+		transient = true
+	}
+	delay = c.cfg.RetryBaseDelay << (attempt - 1)
+	delay = delay/2 + rand.N(delay/2+1)
+	return delay, false, transient
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// record stores the outcome of the last attempt in info
+func record(info *model.LinkInfo, resp *http.Response, err error) {
+	if err != nil {
+		info.ErrorClass, info.Error = classifyLinkError(err), err.Error()
+		return
+	}
+	info.StatusCode = resp.StatusCode
+	if final := resp.Request.URL.String(); final != info.Address {
+		info.FinalURL = final
+	}
+	if location, err := resp.Location(); err == nil && resp.StatusCode/100 == 3 {
+		// A redirect the policy did not follow
+		info.FinalURL = location.String()
+	}
+	// 416 answers the range of an empty resource, which still exists
+	info.Accessible = resp.StatusCode < 400 || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable
+	if !info.Accessible {
+		info.ErrorClass = model.LinkErrorHTTPStatus
+	}
+}
+
+// do sends a body-free request: HEAD, or a GET for the first byte only
+func (c *LinkChecker) do(ctx context.Context, method, link, userAgent string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	return c.client.Do(req)
+}
+
+// headRejected tells whether a HEAD request should be retried as a GET:
+// the server does not allow or implement HEAD, or dropped the connection
+// instead of answering it
+func headRejected(resp *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+	}
+	return resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented
+}
+
+// drain closes resp, reading what is left of a small body first so the
+// connection goes back to the pool
+func drain(resp *http.Response) {
+	io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+	resp.Body.Close()
+}
+
+// classifyLinkError maps a failed request to the error classes of LinkInfo
+func classifyLinkError(err error) string {
+	var (
+		dnsErr     *net.DNSError
+		netErr     net.Error
+		certErr    *tls.CertificateVerificationError
+		alertErr   tls.AlertError
+		recordErr  tls.RecordHeaderError
+		unknownCA  x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+	)
+	switch {
+	case errors.As(err, &dnsErr):
+		return model.LinkErrorDNS
+	case errors.As(err, &certErr), errors.As(err, &alertErr), errors.As(err, &recordErr),
+		errors.As(err, &unknownCA), errors.As(err, &hostErr), errors.As(err, &invalidErr):
+		return model.LinkErrorTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return model.LinkErrorTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return model.LinkErrorRefused
+	}
+	return model.LinkErrorOther
+}
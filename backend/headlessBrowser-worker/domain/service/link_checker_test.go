@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+func TestLinkChecker_FallsBackToRangedGet(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Range"))
+		mu.Unlock()
+		if r.URL.Path == "/no-head" && r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	checker := NewLinkChecker(LinkCheckerConfig{})
+
+	for _, path := range []string{"/head", "/no-head"} {
+		info := model.LinkInfo{Address: srv.URL + path}
+		checker.check(context.Background(), &info, make(chan struct{}, 1), checker.cfg.UserAgent, nil)
+		if !info.Accessible || info.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected an accessible link, got %+v", path, info)
+		}
+	}
+	want := []string{"HEAD /head ", "HEAD /no-head ", "GET /no-head bytes=0-0"}
+	if len(requests) != len(want) {
+		t.Fatalf("expected requests %q, got %q", want, requests)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Errorf("request %d: expected %q, got %q", i, want[i], requests[i])
+		}
+	}
+}
+
+func TestLinkChecker_RedirectPolicy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/local":
+			http.Redirect(w, r, "/landing", http.StatusFound)
+		case "/away":
+			http.Redirect(w, r, target.URL+"/elsewhere", http.StatusMovedPermanently)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		policy, path string
+		status       int
+		finalURL     string
+	}{
+		{RedirectFollow, "/away", http.StatusOK, target.URL + "/elsewhere"},
+		{RedirectSameHost, "/local", http.StatusOK, srv.URL + "/landing"},
+		{RedirectSameHost, "/away", http.StatusMovedPermanently, target.URL + "/elsewhere"},
+		{RedirectNone, "/local", http.StatusFound, srv.URL + "/landing"},
+	}
+	for _, tt := range tests {
+		info := model.LinkInfo{Address: srv.URL + tt.path}
+		checker := NewLinkChecker(LinkCheckerConfig{RedirectPolicy: tt.policy})
+		checker.check(context.Background(), &info, make(chan struct{}, 1), checker.cfg.UserAgent, nil)
+		if info.StatusCode != tt.status || info.FinalURL != tt.finalURL || !info.Accessible {
+			t.Errorf("%s %s: expected %d to %s, got %+v", tt.policy, tt.path, tt.status, tt.finalURL, info)
+		}
+	}
+}
+
+func TestLinkChecker_RetriesTransientFailures(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		n := calls[r.URL.Path]
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/busy" && n == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case r.URL.Path == "/flaky" && n < 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/later":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+	checker := NewLinkChecker(LinkCheckerConfig{RetryBaseDelay: time.Millisecond, PerHostRPS: 1000})
+
+	tests := []struct {
+		path       string
+		accessible bool
+		retries    int
+	}{
+		{"/busy", true, 1},
+		{"/flaky", true, 2},
+		// Waiting an hour is not worth it
+		{"/later", false, 0},
+	}
+	for _, tt := range tests {
+		info := model.LinkInfo{Address: srv.URL + tt.path}
+		checker.check(context.Background(), &info, make(chan struct{}, 1), checker.cfg.UserAgent, nil)
+		if info.Accessible != tt.accessible || info.Retries != tt.retries {
+			t.Errorf("%s: expected accessible %v after %d retries, got %+v", tt.path, tt.accessible, tt.retries, info)
+		}
+	}
+}
+
+func TestLinkChecker_LimitsConcurrencyPerHost(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var links []model.DiscoveredLink
+	for i := 0; i < 8; i++ {
+		links = append(links, model.DiscoveredLink{Href: fmt.Sprintf("/page/%d", i)})
+	}
+	checker := NewLinkChecker(LinkCheckerConfig{PerHostConcurrency: 2, PerHostRPS: 1000})
+	checked := checker.ProcessLinks(context.Background(), srv.URL, links, false, nil)
+
+	if len(checked) != len(links) {
+		t.Fatalf("expected %d links, got %d", len(links), len(checked))
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 requests to the host at once, saw %d", peak)
+	}
+}
+
+func TestLinkChecker_RetryAfterHoldsBackHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/busy" {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+	// Retry-After exceeds MaxRetryDelay, so the first link gives up at once
+	checker := NewLinkChecker(LinkCheckerConfig{PerHostRPS: 1000, MaxRetryDelay: 100 * time.Millisecond})
+
+	busy := model.LinkInfo{Address: srv.URL + "/busy"}
+	checker.check(context.Background(), &busy, make(chan struct{}, 1), checker.cfg.UserAgent, nil)
+	if busy.StatusCode != http.StatusTooManyRequests || busy.Retries != 0 {
+		t.Fatalf("expected /busy to give up with 429, got %+v", busy)
+	}
+
+	start := time.Now()
+	other := model.LinkInfo{Address: srv.URL + "/other"}
+	checker.check(context.Background(), &other, make(chan struct{}, 1), checker.cfg.UserAgent, nil)
+	if !other.Accessible {
+		t.Fatalf("expected /other to be accessible, got %+v", other)
+	}
+	if waited := time.Since(start); waited < 900*time.Millisecond {
+		t.Errorf("expected the host to be held back for its Retry-After, waited %v", waited)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"soon", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := parseRetryAfter(tt.value, now); got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHostLimiter_BlockExpires(t *testing.T) {
+	l := newHostLimiter(1, 1000)
+	// A Retry-After of a day must not hold the host back for a day
+	l.block("example.com", time.Now().Add(24*time.Hour))
+	l.mu.Lock()
+	until := l.state("example.com").blockedUntil
+	l.mu.Unlock()
+	if time.Until(until) > maxHostBlock {
+		t.Errorf("expected the block to end within %v, ends in %v", maxHostBlock, time.Until(until))
+	}
+}
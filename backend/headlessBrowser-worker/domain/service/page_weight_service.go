@@ -0,0 +1,100 @@
+package service
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+const largestResources = 10
+
+// MeasurePageWeight adds up the bytes of the requests a render made, by
+// resource type and by party. Requests for data: and blob: URLs never hit
+// the network and are left out.
+func MeasurePageWeight(pageURL string, entries []model.NetworkEntry) model.PageWeight {
+	weight := model.PageWeight{ByType: make(map[string]model.WeightBucket)}
+	site := registrableDomain(pageURL)
+	for _, e := range entries {
+		u, err := url.Parse(e.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		thirdParty := site != "" && registrableDomain(e.URL) != site
+		kind := resourceGroup(e.ResourceType)
+
+		addWeight(&weight.WeightBucket, e)
+		bucket := weight.ByType[kind]
+		addWeight(&bucket, e)
+		weight.ByType[kind] = bucket
+		if thirdParty {
+			addWeight(&weight.ThirdParty, e)
+		} else {
+			addWeight(&weight.FirstParty, e)
+		}
+		weight.Largest = append(weight.Largest, model.ResourceWeight{
+			URL:           e.URL,
+			Type:          kind,
+			ThirdParty:    thirdParty,
+			TransferBytes: e.TransferSize,
+			ContentBytes:  e.ContentSize,
+		})
+	}
+	sort.SliceStable(weight.Largest, func(i, j int) bool {
+		return weight.Largest[i].TransferBytes > weight.Largest[j].TransferBytes
+	})
+	if len(weight.Largest) > largestResources {
+		weight.Largest = weight.Largest[:largestResources]
+	}
+	return weight
+}
+
+func addWeight(b *model.WeightBucket, e model.NetworkEntry) {
+	b.Requests++
+	b.TransferBytes += e.TransferSize
+	b.ContentBytes += e.ContentSize
+}
+
+// resourceGroup maps Chrome's resource types onto the groups of PageWeight
+func resourceGroup(resourceType string) string {
+	switch strings.ToLower(resourceType) {
+	case "document":
+		return model.ResourceDocument
+	case "script":
+		return model.ResourceScript
+	case "stylesheet":
+		return model.ResourceStylesheet
+	case "image":
+		return model.ResourceImage
+	case "font":
+		return model.ResourceFont
+	case "media", "texttrack":
+		return model.ResourceMedia
+	case "xhr", "fetch":
+		return model.ResourceXHR
+	}
+	return model.ResourceOther
+}
+
+// registrableDomain is the eTLD+1 of rawURL, e.g. example.co.uk for
+// cdn.example.co.uk. IPs and single-label hosts like localhost are their
+// own site.
+func registrableDomain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
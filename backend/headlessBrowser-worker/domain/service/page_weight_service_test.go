@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+func TestMeasurePageWeight(t *testing.T) {
+	entries := []model.NetworkEntry{
+		{URL: "https://www.example.co.uk/", ResourceType: "Document", TransferSize: 20_000, ContentSize: 80_000},
+		{URL: "https://cdn.example.co.uk/app.js", ResourceType: "Script", TransferSize: 300_000, ContentSize: 900_000},
+		{URL: "https://other.co.uk/pixel.gif", ResourceType: "Image", TransferSize: 500, ContentSize: 43},
+		{URL: "https://api.example.co.uk/items", ResourceType: "Fetch", TransferSize: 4_000, ContentSize: 12_000},
+		{URL: "https://fonts.example.net/a.woff2", ResourceType: "Font", TransferSize: 50_000, ContentSize: 50_000},
+		// Inline data never touches the network
+		{URL: "data:image/png;base64,AAAA", ResourceType: "Image", ContentSize: 3},
+	}
+
+	weight := MeasurePageWeight("https://www.example.co.uk/", entries)
+
+	if weight.Requests != 5 || weight.TransferBytes != 374_500 || weight.ContentBytes != 1_042_043 {
+		t.Errorf("unexpected totals %+v", weight.WeightBucket)
+	}
+	if xhr := weight.ByType[model.ResourceXHR]; xhr.Requests != 1 || xhr.TransferBytes != 4_000 {
+		t.Errorf("expected fetch grouped as xhr, got %+v", weight.ByType)
+	}
+	if weight.FirstParty.Requests != 3 || weight.ThirdParty.Requests != 2 || weight.ThirdParty.TransferBytes != 50_500 {
+		t.Errorf("unexpected party split %+v / %+v", weight.FirstParty, weight.ThirdParty)
+	}
+	if len(weight.Largest) != 5 || weight.Largest[0].URL != "https://cdn.example.co.uk/app.js" || !weight.Largest[1].ThirdParty {
+		t.Errorf("unexpected largest resources %+v", weight.Largest)
+	}
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	cases := map[string]string{
+		"https://a.b.example.com/x":  "example.com",
+		"https://shop.example.co.uk": "example.co.uk",
+		"http://localhost:3000/":     "localhost",
+		"http://127.0.0.1:8080/":     "127.0.0.1",
+	}
+	for in, want := range cases {
+		if got := registrableDomain(in); got != want {
+			t.Errorf("registrableDomain(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -48,6 +48,7 @@ func traverse(n *html.Node, res *model.AnalysisResult) {
 		case "h1", "h2", "h3", "h4", "h5", "h6":
 			res.HeadingCounts[n.Data]++
 		case "form":
+			res.FormCount++
 			if isLoginForm(n) {
 				res.HasLoginForm = true
 			}
@@ -57,7 +58,11 @@ func traverse(n *html.Node, res *model.AnalysisResult) {
 				if attr.Key == "href" {
 					link := strings.TrimSpace(attr.Val)
 					if link != "" && !strings.HasPrefix(link, "javascript:") {
-						res.DiscoveredLinks = append(res.DiscoveredLinks, link)
+						res.DiscoveredLinks = append(res.DiscoveredLinks, model.DiscoveredLink{
+							Href:    link,
+							Text:    anchorText(n),
+							Element: describeElement(n),
+						})
 					}
 				}
 			}
@@ -120,3 +125,67 @@ func isLoginLink(n *html.Node) bool {
 	}
 	return false
 }
+
+// maxAnchorText caps the anchor text kept per link
+const maxAnchorText = 100
+
+// anchorText is the visible text of an anchor with whitespace collapsed,
+// falling back to the alt text of an image or an aria-label
+func anchorText(n *html.Node) string {
+	var b strings.Builder
+	var alt string
+	var collect func(*html.Node)
+	collect = func(c *html.Node) {
+		switch {
+		case c.Type == html.TextNode:
+			b.WriteString(c.Data)
+			b.WriteByte(' ')
+		case c.Type == html.ElementNode && c.Data == "img" && alt == "":
+			alt = attr(c, "alt")
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			collect(cc)
+		}
+	}
+	collect(n)
+
+	text := strings.Join(strings.Fields(b.String()), " ")
+	for _, fallback := range []string{alt, attr(n, "aria-label"), attr(n, "title")} {
+		if text != "" {
+			break
+		}
+		text = strings.TrimSpace(fallback)
+	}
+	if runes := []rune(text); len(runes) > maxAnchorText {
+		text = string(runes[:maxAnchorText]) + "…"
+	}
+	return text
+}
+
+// landmarks are the page regions an anchor is placed in by describeElement
+var landmarks = map[string]bool{"header": true, "nav": true, "main": true, "aside": true, "footer": true}
+
+// describeElement returns a short selector of n, prefixed with the nearest
+// landmark around it, e.g. "footer a.social"
+func describeElement(n *html.Node) string {
+	selector := simpleSelector(n)
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && landmarks[p.Data] {
+			return simpleSelector(p) + " " + selector
+		}
+	}
+	return selector
+}
+
+// simpleSelector is the tag of n with its id and first two classes
+func simpleSelector(n *html.Node) string {
+	selector := n.Data
+	if id := attr(n, "id"); id != "" {
+		selector += "#" + id
+	}
+	classes := strings.Fields(attr(n, "class"))
+	for _, class := range classes[:min(len(classes), 2)] {
+		selector += "." + class
+	}
+	return selector
+}
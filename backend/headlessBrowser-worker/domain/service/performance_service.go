@@ -0,0 +1,54 @@
+package service
+
+import (
+	"math"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+// threshold is the upper bound of "good" and of "needs improvement"
+type threshold struct{ good, poor float64 }
+
+// Standard thresholds from web.dev; TBT uses the Lighthouse ones
+var (
+	ttfbThreshold = threshold{800, 1800}
+	fcpThreshold  = threshold{1800, 3000}
+	lcpThreshold  = threshold{2500, 4000}
+	clsThreshold  = threshold{0.1, 0.25}
+	tbtThreshold  = threshold{200, 600}
+)
+
+// RatePerformance rates the sampled metrics. DOMContentLoaded and load have
+// no standard thresholds and are reported without a rating.
+func RatePerformance(sample model.VitalsSample) model.Performance {
+	return model.Performance{
+		TTFB:             rateMetric(sample.TTFB, &ttfbThreshold, 0),
+		FCP:              rateMetric(sample.FCP, &fcpThreshold, 0),
+		LCP:              rateMetric(sample.LCP, &lcpThreshold, 0),
+		CLS:              rateMetric(sample.CLS, &clsThreshold, 3),
+		TBT:              rateMetric(sample.TBT, &tbtThreshold, 0),
+		DOMContentLoaded: rateMetric(sample.DOMContentLoaded, nil, 0),
+		Load:             rateMetric(sample.Load, nil, 0),
+	}
+}
+
+// rateMetric rounds value to the given decimals and rates it against t
+func rateMetric(value *float64, t *threshold, decimals int) *model.Metric {
+	if value == nil {
+		return nil
+	}
+	scale := math.Pow(10, float64(decimals))
+	m := &model.Metric{Value: math.Round(*value*scale) / scale}
+	if t == nil {
+		return m
+	}
+	switch {
+	case m.Value <= t.good:
+		m.Rating = model.RatingGood
+	case m.Value <= t.poor:
+		m.Rating = model.RatingNeedsImprovement
+	default:
+		m.Rating = model.RatingPoor
+	}
+	return m
+}
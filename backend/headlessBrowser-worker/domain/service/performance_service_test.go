@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+func TestRatePerformance(t *testing.T) {
+	ms := func(v float64) *float64 { return &v }
+	perf := RatePerformance(model.VitalsSample{
+		TTFB:             ms(800),
+		FCP:              ms(2100.4),
+		LCP:              ms(4000.6),
+		CLS:              ms(0.04321),
+		DOMContentLoaded: ms(950),
+	})
+
+	cases := []struct {
+		name   string
+		metric *model.Metric
+		value  float64
+		rating string
+	}{
+		{"ttfb", perf.TTFB, 800, model.RatingGood},
+		{"fcp", perf.FCP, 2100, model.RatingNeedsImprovement},
+		{"lcp", perf.LCP, 4001, model.RatingPoor},
+		{"cls", perf.CLS, 0.043, model.RatingGood},
+		{"dom_content_loaded", perf.DOMContentLoaded, 950, ""},
+	}
+	for _, c := range cases {
+		if c.metric == nil || c.metric.Value != c.value || c.metric.Rating != c.rating {
+			t.Errorf("%s: expected %v %q, got %+v", c.name, c.value, c.rating, c.metric)
+		}
+	}
+	if perf.TBT != nil || perf.Load != nil {
+		t.Errorf("expected metrics that were not measured to be left out, got %+v %+v", perf.TBT, perf.Load)
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRules are the rules of a robots.txt that apply to one user agent,
+// following RFC 9309
+type robotsRules struct {
+	rules []robotsRule
+	// crawlDelay is the Crawl-delay of the group, 0 if it has none
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// allowAll and disallowAll stand in for a robots.txt that is missing or
+// could not be fetched
+var (
+	allowAll    = robotsRules{}
+	disallowAll = robotsRules{rules: []robotsRule{{pattern: "/"}}}
+)
+
+// robotsGroup is a run of User-agent lines and the rules that follow them
+type robotsGroup struct {
+	agents []string
+	robotsRules
+}
+
+// parseRobots reads a robots.txt and returns the rules for agent, the
+// product token of the crawler. All groups naming agent are merged; if none
+// do, the groups for "*" apply. Unknown lines are ignored.
+func parseRobots(r io.Reader, agent string) robotsRules {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	// inRules is set once the current group has rules, so a following
+	// User-agent line starts a new group
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxRobotsBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, productToken(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			// An empty Disallow allows everything and so adds nothing
+			if value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: normalizeRobotsPath(value)})
+			}
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	var matched, wildcard robotsRules
+	found := false
+	for _, g := range groups {
+		for _, a := range g.agents {
+			switch {
+			case strings.EqualFold(a, agent):
+				found = true
+				matched.merge(g.robotsRules)
+			case a == "*":
+				wildcard.merge(g.robotsRules)
+			}
+		}
+	}
+	if found {
+		return matched
+	}
+	return wildcard
+}
+
+func (r *robotsRules) merge(other robotsRules) {
+	r.rules = append(r.rules, other.rules...)
+	r.crawlDelay = max(r.crawlDelay, other.crawlDelay)
+}
+
+// productToken is the name a User-agent line matches crawlers by, e.g.
+// "Googlebot" of "Googlebot/2.1"
+func productToken(value string) string {
+	if value == "*" {
+		return value
+	}
+	end := strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-' || r == '_')
+	})
+	if end >= 0 {
+		return value[:end]
+	}
+	return value
+}
+
+// allowed tells whether path, with its query, may be fetched. The longest
+// matching pattern decides; on a tie Allow wins.
+func (r robotsRules) allowed(path string) bool {
+	path = normalizeRobotsPath(path)
+	if path == "/robots.txt" {
+		return true
+	}
+	allow, longest := true, -1
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || n == longest && rule.allow {
+			allow, longest = rule.allow, n
+		}
+	}
+	return allow
+}
+
+// matchRobotsPattern matches path against a pattern where * stands for any
+// characters and a trailing $ anchors the end of the path
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
+
+// normalizeRobotsPath brings a path or pattern to one percent-encoding, so
+// that equal paths match as RFC 9309 asks: escaped unreserved characters
+// are decoded ("/%7Euser" is "/~user"), other escapes get upper-case hex
+// and bytes outside ASCII are escaped.
+func normalizeRobotsPath(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			decoded := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(decoded) {
+				b.WriteByte(decoded)
+			} else {
+				b.WriteByte('%')
+				b.WriteByte(hex[decoded>>4])
+				b.WriteByte(hex[decoded&15])
+			}
+			i += 2
+		case c >= 0x80 || c <= ' ':
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// maxRobotsBytes is how much of a robots.txt is read, the minimum RFC
+	// 9309 asks crawlers to parse
+	maxRobotsBytes = 500 << 10
+	// robotsTTL is how long a fetched robots.txt is used; robotsErrorTTL
+	// is shorter so a failing server is asked again soon
+	robotsTTL      = 24 * time.Hour
+	robotsErrorTTL = 10 * time.Minute
+	// maxRobotsRedirects is the number of redirects RFC 9309 asks crawlers
+	// to follow for robots.txt
+	maxRobotsRedirects = 5
+	// maxCrawlDelay caps the Crawl-delay honored between two requests;
+	// CrawlDelayBudget bounds the total wait of a job
+	maxCrawlDelay  = 30 * time.Second
+	maxRobotsHosts = 1000
+)
+
+// robotsCache keeps the robots.txt rules of each origin. Checks of the same
+// origin wait for a single fetch. It is shared by all jobs.
+type robotsCache struct {
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	// ready is closed once rules and expires are set
+	ready   chan struct{}
+	rules   robotsRules
+	expires time.Time
+}
+
+func newRobotsCache() *robotsCache {
+	return &robotsCache{entries: make(map[string]*robotsEntry)}
+}
+
+// get returns the cached rules of origin, calling fetch when there are none
+// or they expired. fetch returns how long its rules may be kept; 0 keeps
+// them only for the caller, e.g. when the fetch was cancelled.
+func (rc *robotsCache) get(ctx context.Context, origin string, fetch func() (robotsRules, time.Duration)) robotsRules {
+	for {
+		rc.mu.Lock()
+		e, ok := rc.entries[origin]
+		if !ok || e.done() && time.Now().After(e.expires) {
+			e = &robotsEntry{ready: make(chan struct{})}
+			rc.entries[origin] = e
+			rc.prune()
+			rc.mu.Unlock()
+
+			rules, ttl := fetch()
+			e.rules, e.expires = rules, time.Now().Add(ttl)
+			close(e.ready)
+			return rules
+		}
+		rc.mu.Unlock()
+
+		select {
+		case <-e.ready:
+		case <-ctx.Done():
+			return allowAll
+		}
+		if time.Now().Before(e.expires) {
+			return e.rules
+		}
+	}
+}
+
+func (e *robotsEntry) done() bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// prune drops expired entries once there are many of them; rc.mu must be
+// held
+func (rc *robotsCache) prune() {
+	if len(rc.entries) <= maxRobotsHosts {
+		return
+	}
+	now := time.Now()
+	for origin, e := range rc.entries {
+		if e.done() && now.After(e.expires) {
+			delete(rc.entries, origin)
+		}
+	}
+}
+
+// robotsAllow tells whether robots.txt lets the checker request link and
+// returns the Crawl-delay of its host. Links that are not http or https are
+// always allowed.
+func (c *LinkChecker) robotsAllow(ctx context.Context, link string, slots chan struct{}) (allowed bool, crawlDelay time.Duration) {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+		return true, 0
+	}
+	origin := u.Scheme + "://" + u.Host
+	rules := c.robots.get(ctx, origin, func() (robotsRules, time.Duration) {
+		return c.fetchRobots(ctx, origin, strings.ToLower(u.Host), slots)
+	})
+	crawlDelay = min(rules.crawlDelay, maxCrawlDelay)
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.allowed(path), crawlDelay
+}
+
+// crawlPacer spaces the requests of one job to each host by the host's
+// Crawl-delay. It belongs to a single job, so the delay neither slows down
+// other jobs nor outlives the job.
+type crawlPacer struct {
+	mu    sync.Mutex
+	hosts map[string]*rate.Limiter
+}
+
+func newCrawlPacer() *crawlPacer {
+	return &crawlPacer{hosts: make(map[string]*rate.Limiter)}
+}
+
+// pace returns a func that waits for the next request to host to be due,
+// or nil if there is no delay
+func (p *crawlPacer) pace(host string, delay time.Duration) func(ctx context.Context) error {
+	if delay <= 0 || host == "" {
+		return nil
+	}
+	p.mu.Lock()
+	limiter, ok := p.hosts[host]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(delay), 1)
+		p.hosts[host] = limiter
+	}
+	p.mu.Unlock()
+	return limiter.Wait
+}
+
+// fetchRobots requests the robots.txt of origin like any other check, within
+// the limits of the host and the job. As RFC 9309 asks, a missing file
+// allows everything and a server error disallows everything. A host that
+// cannot be reached is not held back, so its links fail with their own
+// error.
+func (c *LinkChecker) fetchRobots(ctx context.Context, origin, host string, slots chan struct{}) (robotsRules, time.Duration) {
+	release, err := c.hosts.acquire(ctx, host)
+	if err != nil {
+		return allowAll, 0
+	}
+	defer release()
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return allowAll, 0
+	}
+	defer func() { <-slots }()
+
+	reqCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return allowAll, robotsErrorTTL
+	}
+	req.Header.Set("User-Agent", c.cfg.BotUserAgent)
+	client := *c.client
+	client.CheckRedirect = robotsRedirect
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return allowAll, 0
+		}
+		return allowAll, robotsErrorTTL
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return disallowAll, robotsErrorTTL
+	case resp.StatusCode >= 300:
+		// Missing, forbidden or redirected too often all count as no robots.txt
+		return allowAll, robotsTTL
+	}
+	return parseRobots(io.LimitReader(resp.Body, maxRobotsBytes), c.cfg.RobotsAgent), robotsTTL
+}
+
+// robotsRedirect stops at the last redirect once maxRobotsRedirects have
+// been followed
+func robotsRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > maxRobotsRedirects {
+		return http.ErrUseLastResponse
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"headlessBrowser-worker/domain/model"
+)
+
+const testRobots = `# comments and unknown lines are ignored
+Sitemap: https://example.com/sitemap.xml
+
+User-agent: *
+Disallow: /
+
+User-agent: Snappy-Analyzer/1.0
+User-agent: otherbot
+Disallow: /private
+Allow: /private/open
+Disallow: /*.pdf$
+Disallow: /search?q=*&page=
+Crawl-delay: 2
+
+User-agent: snappy-analyzer
+Allow: /private/shared$
+`
+
+func TestParseRobots(t *testing.T) {
+	rules := parseRobots(strings.NewReader(testRobots), "snappy-analyzer")
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/private", false},
+		{"/private/secret", false},
+		{"/private/open/page", true},
+		{"/private/shared", true},
+		{"/private/shared/more", false},
+		{"/docs/report.pdf", false},
+		{"/docs/report.pdf?download=1", true},
+		{"/search?q=go&page=2", false},
+		{"/search?q=go", true},
+		{"/robots.txt", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.path); got != tt.allowed {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.allowed)
+		}
+	}
+	if rules.crawlDelay != 2*time.Second {
+		t.Errorf("expected a crawl delay of 2s, got %v", rules.crawlDelay)
+	}
+
+	others := parseRobots(strings.NewReader(testRobots), "somebot")
+	if others.allowed("/about") {
+		t.Error("expected the * group to disallow everything for other agents")
+	}
+}
+
+func TestMatchRobotsPattern(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		match         bool
+	}{
+		{"/fish", "/fish.html", true},
+		{"/fish", "/Fish", false},
+		{"/fish*", "/fishheads", true},
+		{"/*.php", "/folder/index.php?x=1", true},
+		{"/*.php$", "/index.php?x=1", false},
+		{"/*.php$", "/a/index.php", true},
+		{"/fish$", "/fish", true},
+		{"/fish$", "/fish/", false},
+		{"*", "/anything", true},
+	}
+	for _, tt := range tests {
+		if got := matchRobotsPattern(tt.pattern, tt.path); got != tt.match {
+			t.Errorf("matchRobotsPattern(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.match)
+		}
+	}
+}
+
+func TestProcessLinks_RespectsRobots(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	agents := make(map[string]bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		agents[r.UserAgent()] = true
+		mu.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /admin\n"))
+		}
+	}))
+	defer srv.Close()
+	links := []model.DiscoveredLink{{Href: "/"}, {Href: "/admin/users"}, {Href: "/about"}}
+
+	checker := NewLinkChecker(LinkCheckerConfig{PerHostRPS: 1000})
+	checked := checker.ProcessLinks(context.Background(), srv.URL, links, true, nil)
+	if len(checked) != 3 {
+		t.Fatalf("expected 3 links, got %d", len(checked))
+	}
+	if checked[1].Skipped != model.LinkSkippedRobots || checked[1].StatusCode != 0 {
+		t.Errorf("expected /admin/users to be skipped, got %+v", checked[1])
+	}
+	for _, i := range []int{0, 2} {
+		if !checked[i].Accessible || checked[i].Skipped != "" {
+			t.Errorf("expected %s to be checked, got %+v", checked[i].Address, checked[i])
+		}
+	}
+	// A second job uses the cached robots.txt
+	checker.ProcessLinks(context.Background(), srv.URL, links, true, nil)
+
+	mu.Lock()
+	if len(agents) != 1 || !agents[checker.cfg.BotUserAgent] || !strings.Contains(checker.cfg.BotUserAgent, "snappy-analyzer") {
+		t.Errorf("expected only the bot user agent naming the robots token, got %v", agents)
+	}
+	robots := 0
+	for _, path := range requests {
+		if path == "/robots.txt" {
+			robots++
+		}
+		if strings.HasPrefix(path, "/admin") {
+			t.Errorf("expected no request to %s", path)
+		}
+	}
+	mu.Unlock()
+	if robots != 1 {
+		t.Errorf("expected robots.txt to be fetched once, got %d", robots)
+	}
+
+	ignored := checker.ProcessLinks(context.Background(), srv.URL, links, false, nil)
+	if ignored[1].Skipped != "" {
+		t.Errorf("expected /admin/users to be checked when robots.txt is ignored, got %+v", ignored[1])
+	}
+}
+
+func TestFetchRobots_ServerErrorDisallows(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	checker := NewLinkChecker(LinkCheckerConfig{PerHostRPS: 1000})
+	if allowed, _ := checker.robotsAllow(context.Background(), srv.URL+"/page", make(chan struct{}, 1)); allowed {
+		t.Error("expected a failing robots.txt to disallow the host")
+	}
+}
+
+func TestProcessLinks_CrawlDelayBudget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nCrawl-delay: 0.05\n"))
+		}
+	}))
+	defer srv.Close()
+	var links []model.DiscoveredLink
+	for _, path := range []string{"/a", "/b", "/c", "/d", "/e"} {
+		links = append(links, model.DiscoveredLink{Href: path})
+	}
+
+	checker := NewLinkChecker(LinkCheckerConfig{PerHostRPS: 1000, CrawlDelayBudget: 100 * time.Millisecond})
+	checked := checker.ProcessLinks(context.Background(), srv.URL, links, true, nil)
+	for i, link := range checked {
+		// /a, /b and /c fit into the budget at 50ms apart, the rest would not
+		want := ""
+		if i >= 3 {
+			want = model.LinkSkippedCrawlDelay
+		}
+		if link.Skipped != want {
+			t.Errorf("%s: expected skipped %q, got %+v", link.Address, want, link)
+		}
+	}
+}
+
+func TestRobotsRules_NormalizesPercentEncoding(t *testing.T) {
+	rules := parseRobots(strings.NewReader("User-agent: *\nDisallow: /%7Ejoe\nDisallow: /~anna\nDisallow: /café\nDisallow: /a%2fb\n"), "snappy-analyzer")
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/~joe/index.html", false},
+		{"/%7eanna", false},
+		{"/caf%C3%A9/menu", false},
+		{"/a%2Fb", false},
+		// An escaped slash is not a path separator
+		{"/a/b", true},
+		{"/%7Ebob", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.path); got != tt.allowed {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.allowed)
+		}
+	}
+}
+
+func TestProcessLinks_CrawlDelayStaysInTheJob(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nCrawl-delay: 0.2\n"))
+		}
+	}))
+	defer srv.Close()
+	links := []model.DiscoveredLink{{Href: "/a"}, {Href: "/b"}, {Href: "/c"}}
+	checker := NewLinkChecker(LinkCheckerConfig{PerHostRPS: 1000})
+
+	start := time.Now()
+	checker.ProcessLinks(context.Background(), srv.URL, links, true, nil)
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected three links 200ms apart, took %v", elapsed)
+	}
+
+	// Neither a later job nor one ignoring robots.txt inherits the delay
+	for _, respectRobots := range []bool{false, true} {
+		start = time.Now()
+		checker.ProcessLinks(context.Background(), srv.URL, links[:1], respectRobots, nil)
+		if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+			t.Errorf("respectRobots=%v: expected the first link of a new job at once, took %v", respectRobots, elapsed)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// minShellText is the visible text below which a page that loads scripts is
+// taken to be rendered client-side
+const minShellText = 200
+
+// mountPoints are the element ids frameworks render into
+var mountPoints = map[string]bool{
+	"root": true, "app": true, "__next": true, "__nuxt": true, "___gatsby": true, "svelte": true,
+}
+
+// DetectSPAShell tells whether a statically fetched page looks like the
+// empty shell of a single-page app that only gets its content from
+// JavaScript. It returns why, or "" if the page seems complete.
+func DetectSPAShell(htmlDoc string) string {
+	doc, err := html.Parse(strings.NewReader(htmlDoc))
+	if err != nil {
+		return ""
+	}
+	var s shellStats
+	s.walk(doc)
+
+	switch {
+	case s.emptyMount != "":
+		return "empty mount point " + s.emptyMount
+	case s.scripts > 0 && s.text < minShellText:
+		return "scripts but almost no text"
+	}
+	return ""
+}
+
+type shellStats struct {
+	scripts int
+	// text counts the visible characters of the body
+	text       int
+	emptyMount string
+}
+
+func (s *shellStats) walk(n *html.Node) {
+	if n.Type == html.ElementNode {
+		switch n.Data {
+		case "script":
+			s.scripts++
+			return
+		case "style", "noscript", "template", "title":
+			return
+		}
+		if id := attr(n, "id"); s.emptyMount == "" && (mountPoints[id] || n.Data == "app-root") && !hasElementChild(n) {
+			if id != "" {
+				s.emptyMount = "#" + id
+			} else {
+				s.emptyMount = n.Data
+			}
+		}
+	}
+	if n.Type == html.TextNode {
+		s.text += utf8.RuneCountInString(strings.TrimSpace(n.Data))
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		s.walk(c)
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasElementChild(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectSPAShell(t *testing.T) {
+	article := "<p>" + strings.Repeat("Plenty of server-rendered text. ", 10) + "</p>"
+	tests := []struct {
+		name  string
+		html  string
+		shell bool
+	}{
+		{"react shell", `<html><head><script src="/main.js"></script></head><body><div id="root"></div></body></html>`, true},
+		{"angular shell", `<body><app-root></app-root><script src="main.js"></script></body>`, true},
+		{"scripts and a spinner", `<body><div class="spinner">Loading…</div><script src="app.js"></script></body>`, true},
+		{"server-rendered next page", `<body><div id="__next"><main>` + article + `</main></div><script src="/_next/app.js"></script></body>`, false},
+		{"static page", `<body><h1>Docs</h1>` + article + `</body>`, false},
+		{"short page without scripts", `<body><p>Hello</p></body>`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reason := DetectSPAShell(tt.html); (reason != "") != tt.shell {
+				t.Errorf("DetectSPAShell() = %q, want shell %v", reason, tt.shell)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"webSocket-server/application/socket"
+	"webSocket-server/domain/model"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// The stream is declared and owned by the headless worker; the socket
+// server only reads from it
+const (
+	EventsStream        = "ANALYSIS_EVENTS"
+	EventsSubjectPrefix = "analysis.events"
+	// streamRetryDelay is how often the subscriber looks for the stream
+	// until the worker has declared it
+	streamRetryDelay = 2 * time.Second
+)
+
+// NATSSubscriber feeds job events from JetStream into the Hub. Each socket
+// server reads every event through its own ordered consumer, so a client
+// gets its events whichever instance it is connected to. Events published
+// before a client subscribed are read back by Events.
+type NATSSubscriber struct {
+	UseCase *socket.BroadcastUseCase
+
+	js jetstream.JetStream
+}
+
+// Start begins consuming once the stream exists; stop it with the returned
+// func
+func (s *NATSSubscriber) Start(nc *nats.Conn) (stop func(), err error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+	s.js = js
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.consume(ctx)
+	return cancel, nil
+}
+
+// consume waits for the worker to declare the stream and then delivers the
+// events published from now on until ctx ends
+func (s *NATSSubscriber) consume(ctx context.Context) {
+	for {
+		consumer, err := s.js.OrderedConsumer(ctx, EventsStream, jetstream.OrderedConsumerConfig{
+			FilterSubjects: []string{EventsSubjectPrefix + ".>"},
+			// Earlier events are replayed per job when a client subscribes
+			DeliverPolicy: jetstream.DeliverNewPolicy,
+		})
+		if err == nil {
+			cc, err := consumer.Consume(s.handle)
+			if err == nil {
+				<-ctx.Done()
+				cc.Stop()
+				return
+			}
+			slog.Warn("failed to consume events", "stream", EventsStream, "error", err)
+		} else if !errors.Is(err, jetstream.ErrStreamNotFound) && ctx.Err() == nil {
+			slog.Warn("failed to create events consumer", "stream", EventsStream, "error", err)
+		}
+
+		select {
+		case <-time.After(streamRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *NATSSubscriber) handle(msg jetstream.Msg) {
+	event, err := decodeEvent(msg)
+	if err != nil {
+		slog.Error("dropping invalid event", "subject", msg.Subject(), "error", err)
+		return
+	}
+	s.UseCase.Publish(event)
+}
+
+// Events reads the events of jobID still kept in the stream, oldest first
+func (s *NATSSubscriber) Events(ctx context.Context, jobID string) ([]model.Message, error) {
+	// A job ID with subject syntax would match the events of other jobs
+	if jobID == "" || strings.ContainsAny(jobID, ".*> \t") {
+		return nil, nil
+	}
+	subject := EventsSubjectPrefix + "." + jobID
+
+	stream, err := s.js.Stream(ctx, EventsStream)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up stream %s: %w", EventsStream, err)
+	}
+	last, err := stream.GetLastMsgForSubject(ctx, subject)
+	if errors.Is(err, jetstream.ErrMsgNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read last event of %s: %w", jobID, err)
+	}
+
+	consumer, err := stream.OrderedConsumer(ctx, jetstream.OrderedConsumerConfig{
+		FilterSubjects:    []string{subject},
+		InactiveThreshold: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events of %s: %w", jobID, err)
+	}
+	var events []model.Message
+	for {
+		msg, err := consumer.Next(jetstream.FetchContext(ctx))
+		if err != nil {
+			return events, fmt.Errorf("failed to read events of %s: %w", jobID, err)
+		}
+		if event, err := decodeEvent(msg); err == nil {
+			events = append(events, event)
+		}
+		meta, err := msg.Metadata()
+		if err != nil || meta.Sequence.Stream >= last.Sequence {
+			return events, nil
+		}
+	}
+}
+
+// decodeEvent parses a job event and stamps it with its stream sequence
+func decodeEvent(msg jetstream.Msg) (model.Message, error) {
+	var event model.Message
+	if err := json.Unmarshal(msg.Data(), &event); err != nil {
+		return event, err
+	}
+	if event.JobID == "" {
+		return event, errors.New("event without job_id")
+	}
+	meta, err := msg.Metadata()
+	if err != nil {
+		return event, err
+	}
+	event.Seq = meta.Sequence.Stream
+	return event, nil
+}
@@ -0,0 +1,142 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webSocket-server/application/socket"
+	"webSocket-server/domain/model"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// runServer starts an embedded nats-server with JetStream on a random port
+func runServer(t *testing.T) *nats.Conn {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to create nats-server: %v", err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats-server not ready")
+	}
+	t.Cleanup(s.Shutdown)
+
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+// declareStream creates the events stream as the headless worker does
+func declareStream(t *testing.T, ctx context.Context, nc *nats.Conn) jetstream.JetStream {
+	t.Helper()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatalf("failed to open JetStream: %v", err)
+	}
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     EventsStream,
+		Subjects: []string{EventsSubjectPrefix + ".>"},
+	})
+	if err != nil {
+		t.Fatalf("failed to declare stream: %v", err)
+	}
+	return js
+}
+
+// waitForConsumers waits until n subscribers consume the stream, since
+// events published before that are not delivered live
+func waitForConsumers(t *testing.T, ctx context.Context, js jetstream.JetStream, n int) {
+	t.Helper()
+	for {
+		stream, err := js.Stream(ctx, EventsStream)
+		if err == nil {
+			if info, err := stream.Info(ctx); err == nil && info.State.Consumers >= n {
+				return
+			}
+		}
+		select {
+		case <-time.After(20 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatalf("expected %d consumers on the stream", n)
+		}
+	}
+}
+
+func TestNATSSubscriber_EveryInstanceGetsEvents(t *testing.T) {
+	nc := runServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The socket servers start before the worker has declared the stream
+	hubs := []*model.Hub{model.NewHub(), model.NewHub()}
+	for _, hub := range hubs {
+		sub := &NATSSubscriber{UseCase: &socket.BroadcastUseCase{Hub: hub}}
+		stop, err := sub.Start(nc)
+		if err != nil {
+			t.Fatalf("failed to start subscriber: %v", err)
+		}
+		defer stop()
+	}
+	js := declareStream(t, ctx, nc)
+	waitForConsumers(t, ctx, js, len(hubs))
+
+	payload := `{"job_id":"job-1","type":"result","data":{"page_title":"Hi"}}`
+	if _, err := js.Publish(ctx, EventsSubjectPrefix+".job-1", []byte(payload)); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	for i, hub := range hubs {
+		select {
+		case msg := <-hub.Broadcast:
+			if msg.JobID != "job-1" || msg.Type != "result" || string(msg.Data) != `{"page_title":"Hi"}` || msg.Seq != 1 {
+				t.Errorf("instance %d: unexpected message %+v", i, msg)
+			}
+		case <-ctx.Done():
+			t.Fatalf("event never reached the hub of instance %d", i)
+		}
+	}
+}
+
+func TestNATSSubscriber_EventsReplaysOneJob(t *testing.T) {
+	nc := runServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sub := &NATSSubscriber{UseCase: &socket.BroadcastUseCase{Hub: model.NewHub()}}
+	stop, err := sub.Start(nc)
+	if err != nil {
+		t.Fatalf("failed to start subscriber: %v", err)
+	}
+	defer stop()
+	if events, err := sub.Events(ctx, "job-1"); err != nil || len(events) != 0 {
+		t.Fatalf("expected no history before the stream exists, got %v, %v", events, err)
+	}
+
+	js := declareStream(t, ctx, nc)
+	for _, e := range []struct{ job, typ string }{{"job-1", "render_started"}, {"job-2", "render_started"}, {"job-1", "result"}} {
+		payload := `{"job_id":"` + e.job + `","type":"` + e.typ + `"}`
+		if _, err := js.Publish(ctx, EventsSubjectPrefix+"."+e.job, []byte(payload)); err != nil {
+			t.Fatalf("publish failed: %v", err)
+		}
+	}
+
+	events, err := sub.Events(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("failed to read history: %v", err)
+	}
+	if len(events) != 2 || events[0].Type != "render_started" || events[0].Seq != 1 || events[1].Type != "result" || events[1].Seq != 3 {
+		t.Errorf("unexpected history %+v", events)
+	}
+	for _, jobID := range []string{"unknown", "*", "job-1.>"} {
+		if events, err := sub.Events(ctx, jobID); err != nil || len(events) != 0 {
+			t.Errorf("%s: expected no history, got %v, %v", jobID, events, err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"webSocket-server/application/socket"
+	"webSocket-server/domain/model"
 
 	"github.com/gorilla/websocket"
 )
@@ -17,6 +18,12 @@ type SocketHandler struct {
 	UseCase *socket.BroadcastUseCase
 }
 
+// subscribeRequest is sent by a client to receive the messages of a job
+type subscribeRequest struct {
+	Action string `json:"action"`
+	JobID  string `json:"job_id"`
+}
+
 func (h *SocketHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
 	l := logger.Scoped("socket", "user", "ws_conn", r.URL.Path, r.Method)
 
@@ -26,29 +33,49 @@ func (h *SocketHandler) HandleWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.UseCase.Hub.Clients[ws] = true
+	hub := h.UseCase.Hub
+	hub.Register(ws)
 	l.Info("Client connected")
 
-	// Keep alive until client closes
+	// Allow subscribing straight away with /ws?job_id=...
+	if jobID := r.URL.Query().Get("job_id"); jobID != "" {
+		h.UseCase.Subscribe(ws, jobID)
+		l.Info("Client subscribed", "job_id", jobID)
+	}
+
+	// Keep alive until client closes, handling subscribe requests
 	for {
-		if _, _, err := ws.ReadMessage(); err != nil {
-			delete(h.UseCase.Hub.Clients, ws)
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			hub.Unregister(ws)
 			l.Info("Client disconnected")
 			break
 		}
+
+		var req subscribeRequest
+		if err := json.Unmarshal(data, &req); err != nil || req.Action != "subscribe" || req.JobID == "" {
+			l.Debug("ignoring client message", "message", string(data))
+			continue
+		}
+		h.UseCase.Subscribe(ws, req.JobID)
+		l.Info("Client subscribed", "job_id", req.JobID)
 	}
 }
 
 func (h *SocketHandler) HandlePublish(w http.ResponseWriter, r *http.Request) {
 	l := logger.Scoped("socket", "worker", "pub_req", r.URL.Path, r.Method)
 
-	var data interface{}
-	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+	var msg model.Message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
 		l.Error("decode failed", "error", err)
 		http.Error(w, "Invalid JSON", 400)
 		return
 	}
+	if msg.JobID == "" {
+		http.Error(w, "Missing job_id", 400)
+		return
+	}
 
-	h.UseCase.Publish(data)
+	h.UseCase.Publish(msg)
 	w.WriteHeader(http.StatusAccepted)
 }
@@ -1,29 +1,88 @@
 package socket
 
 import (
+	"context"
 	"log/slog"
+	"time"
 	"webSocket-server/domain/model"
+
+	"github.com/gorilla/websocket"
 )
 
+// historyTimeout bounds the replay of a job's earlier events, during which
+// no other message is delivered
+const historyTimeout = 2 * time.Second
+
+// EventHistory returns the events already published for a job, oldest first
+type EventHistory interface {
+	Events(ctx context.Context, jobID string) ([]model.Message, error)
+}
+
 type BroadcastUseCase struct {
 	Hub *model.Hub
+	// History, when set, replays the earlier events of a job to a client
+	// that subscribes to it late, e.g. after a socket-service restart
+	History EventHistory
 }
 
-// Run starts the infinite loop to listen for messages
+// Run starts the infinite loop to listen for messages and deliver each one
+// only to the clients subscribed to its job. It is the only writer of the
+// connections.
 func (uc *BroadcastUseCase) Run() {
 	for {
-		msg := <-uc.Hub.Broadcast
-		for client := range uc.Hub.Clients {
-			err := client.WriteJSON(msg)
-			if err != nil {
-				slog.Error("websocket write error", "error", err)
-				client.Close()
-				delete(uc.Hub.Clients, client)
+		select {
+		case msg := <-uc.Hub.Broadcast:
+			for _, client := range uc.Hub.Recipients(msg) {
+				uc.send(client, msg)
+			}
+		case join := <-uc.Hub.Joins:
+			uc.join(join)
+		}
+	}
+}
+
+func (uc *BroadcastUseCase) Publish(msg model.Message) {
+	uc.Hub.Broadcast <- msg
+}
+
+// Subscribe makes conn receive the messages of jobID, starting with the
+// ones published before it subscribed
+func (uc *BroadcastUseCase) Subscribe(conn *websocket.Conn, jobID string) {
+	uc.Hub.Joins <- model.Join{Conn: conn, JobID: jobID}
+}
+
+// join subscribes first and then replays, so an event is either in the
+// history or delivered live; Recipients drops live events already replayed.
+// Only the joining client gets the "subscribed" confirmation, after which
+// it may submit the job.
+func (uc *BroadcastUseCase) join(j model.Join) {
+	if !uc.Hub.Subscribe(j.Conn, j.JobID) {
+		return
+	}
+	if uc.History != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+		defer cancel()
+		events, err := uc.History.Events(ctx, j.JobID)
+		if err != nil {
+			slog.Warn("failed to read job history", "error", err, "job_id", j.JobID)
+		}
+		for _, msg := range events {
+			if !uc.send(j.Conn, msg) {
+				return
 			}
+			uc.Hub.Replayed(j.Conn, j.JobID, msg.Seq)
 		}
 	}
+	uc.send(j.Conn, model.Message{JobID: j.JobID, Type: model.TypeSubscribed})
 }
 
-func (uc *BroadcastUseCase) Publish(data interface{}) {
-	uc.Hub.Broadcast <- data
+// send writes msg to client and drops the client if that fails
+func (uc *BroadcastUseCase) send(client *websocket.Conn, msg model.Message) bool {
+	if err := client.WriteJSON(msg); err != nil {
+		slog.Error("websocket write error", "error", err, "job_id", msg.JobID)
+		client.Close()
+		uc.Hub.Unregister(client)
+		return false
+	}
+	return true
 }
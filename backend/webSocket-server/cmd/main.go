@@ -4,8 +4,11 @@ import (
 	"common/logger"
 	"log/slog"
 	"net/http"
+	"os"
+	"webSocket-server/adapter/messaging"
 	"webSocket-server/api/http/handle"
 	"webSocket-server/application/socket"
+	"webSocket-server/config"
 	"webSocket-server/domain/model"
 )
 
@@ -15,12 +18,18 @@ func main() {
 		ServiceName: "socket-server",
 		Level:       slog.LevelDebug,
 	})
+	cfg := config.Load()
 
 	// 2. Initialize DDD Layers
 	hub := model.NewHub()
 	useCase := &socket.BroadcastUseCase{Hub: hub}
 	handler := &handle.SocketHandler{UseCase: useCase}
 
+	// Optionally also receive events from NATS
+	if cfg.NatsURL != "" {
+		startNATSSubscriber(cfg, useCase)
+	}
+
 	// 3. Start Background Broadcaster
 	go useCase.Run()
 
@@ -33,8 +42,24 @@ func main() {
 		w.Write([]byte("OK"))
 	})
 
-	slog.Info("Socket server starting", "port", 8081)
-	if err := http.ListenAndServe(":8081", mux); err != nil {
+	slog.Info("Socket server starting", "addr", cfg.Addr)
+	if err := http.ListenAndServe(cfg.Addr, mux); err != nil {
 		slog.Error("server failed", "error", err)
 	}
 }
+
+func startNATSSubscriber(cfg config.Config, useCase *socket.BroadcastUseCase) {
+	nc, err := messaging.Connect(cfg.NatsURL, "socket-server")
+	if err != nil {
+		slog.Error("nats connect failed", "url", cfg.NatsURL, "error", err)
+		os.Exit(1)
+	}
+	sub := &messaging.NATSSubscriber{UseCase: useCase}
+	if _, err := sub.Start(nc); err != nil {
+		slog.Error("nats subscriber setup failed", "error", err)
+		os.Exit(1)
+	}
+	// Clients that subscribe late get the events of their job from the stream
+	useCase.History = sub
+	slog.Info("consuming events from NATS", "url", cfg.NatsURL, "stream", messaging.EventsStream)
+}
@@ -0,0 +1,24 @@
+package config
+
+import "os"
+
+// Config holds the runtime settings of the socket server, read from the environment
+type Config struct {
+	Addr string
+	// NatsURL enables the NATS subscriber when set; HTTP /publish stays available
+	NatsURL string
+}
+
+func Load() Config {
+	return Config{
+		Addr:    getEnv("SOCKET_ADDR", ":8081"),
+		NatsURL: os.Getenv("NATS_URL"),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
@@ -1,16 +1,125 @@
 package model
 
-import "github.com/gorilla/websocket"
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Message is an event published for a single analysis job. Type tells the
+// client whether it is a progress update or the final result.
+type Message struct {
+	JobID string          `json:"job_id"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	// Seq is the stream sequence of an event read from NATS, 0 for one
+	// posted to /publish
+	Seq uint64 `json:"-"`
+}
+
+// Join asks the broadcast loop to subscribe Conn to JobID
+type Join struct {
+	Conn  *websocket.Conn
+	JobID string
+}
+
+// TypeSubscribed confirms to a client that it receives the messages of a job
+const TypeSubscribed = "subscribed"
 
 // Hub handles the low-level client state
 type Hub struct {
 	Clients   map[*websocket.Conn]bool
-	Broadcast chan interface{}
+	Broadcast chan Message
+	Joins     chan Join
+
+	mu sync.RWMutex
+	// subscriptions maps a job ID to the connections waiting for its result
+	// and the last stream sequence replayed to each of them
+	subscriptions map[string]map[*websocket.Conn]uint64
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		Clients:   make(map[*websocket.Conn]bool),
-		Broadcast: make(chan interface{}),
+		Clients:       make(map[*websocket.Conn]bool),
+		Broadcast:     make(chan Message),
+		Joins:         make(chan Join),
+		subscriptions: make(map[string]map[*websocket.Conn]uint64),
+	}
+}
+
+// Register adds a newly connected client
+func (h *Hub) Register(conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Clients[conn] = true
+}
+
+// Unregister removes a client and all of its job subscriptions
+func (h *Hub) Unregister(conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.Clients, conn)
+	for jobID, conns := range h.subscriptions {
+		delete(conns, conn)
+		if len(conns) == 0 {
+			delete(h.subscriptions, jobID)
+		}
+	}
+}
+
+// Subscribe makes conn receive every message published for jobID. It
+// returns false if conn is no longer connected.
+func (h *Hub) Subscribe(conn *websocket.Conn, jobID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.Clients[conn] {
+		return false
+	}
+	conns, ok := h.subscriptions[jobID]
+	if !ok {
+		conns = make(map[*websocket.Conn]uint64)
+		h.subscriptions[jobID] = conns
+	}
+	if _, ok := conns[conn]; !ok {
+		conns[conn] = 0
+	}
+	return true
+}
+
+// Replayed records that conn was sent the events of jobID up to stream
+// sequence seq, so Recipients leaves them out when they arrive live
+func (h *Hub) Replayed(conn *websocket.Conn, jobID string, seq uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if conns, ok := h.subscriptions[jobID]; ok {
+		if _, ok := conns[conn]; ok {
+			conns[conn] = max(conns[conn], seq)
+		}
+	}
+}
+
+// Subscribers returns a snapshot of the connections subscribed to jobID
+func (h *Hub) Subscribers(jobID string) []*websocket.Conn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	conns := make([]*websocket.Conn, 0, len(h.subscriptions[jobID]))
+	for conn := range h.subscriptions[jobID] {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// Recipients returns the subscribers of msg's job that did not already get
+// it in a replay
+func (h *Hub) Recipients(msg Message) []*websocket.Conn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	conns := make([]*websocket.Conn, 0, len(h.subscriptions[msg.JobID]))
+	for conn, replayed := range h.subscriptions[msg.JobID] {
+		if msg.Seq == 0 || msg.Seq > replayed {
+			conns = append(conns, conn)
+		}
 	}
+	return conns
 }
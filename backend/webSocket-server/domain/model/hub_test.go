@@ -0,0 +1,71 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestHub_RoutesByJob(t *testing.T) {
+	hub := NewHub()
+	alice, bob := &websocket.Conn{}, &websocket.Conn{}
+	hub.Register(alice)
+	hub.Register(bob)
+
+	hub.Subscribe(alice, "job-a")
+	hub.Subscribe(bob, "job-b")
+
+	if got := hub.Subscribers("job-a"); len(got) != 1 || got[0] != alice {
+		t.Errorf("job-a: expected only alice, got %v", got)
+	}
+	if got := hub.Subscribers("job-b"); len(got) != 1 || got[0] != bob {
+		t.Errorf("job-b: expected only bob, got %v", got)
+	}
+	if got := hub.Subscribers("unknown"); len(got) != 0 {
+		t.Errorf("unknown job: expected no subscribers, got %d", len(got))
+	}
+}
+
+func TestHub_UnregisterDropsSubscriptions(t *testing.T) {
+	hub := NewHub()
+	conn := &websocket.Conn{}
+	hub.Register(conn)
+	hub.Subscribe(conn, "job-a")
+
+	hub.Unregister(conn)
+
+	if got := hub.Subscribers("job-a"); len(got) != 0 {
+		t.Errorf("expected no subscribers after unregister, got %d", len(got))
+	}
+	// A closed connection must not be able to subscribe again
+	hub.Subscribe(conn, "job-a")
+	if got := hub.Subscribers("job-a"); len(got) != 0 {
+		t.Errorf("expected unregistered conn to be ignored, got %d", len(got))
+	}
+}
+
+func TestHub_RecipientsSkipReplayedEvents(t *testing.T) {
+	hub := NewHub()
+	early, late := &websocket.Conn{}, &websocket.Conn{}
+	hub.Register(early)
+	hub.Register(late)
+	hub.Subscribe(early, "job-a")
+	hub.Subscribe(late, "job-a")
+	// late joined after events 1 to 3 and was sent them from the stream
+	hub.Replayed(late, "job-a", 3)
+
+	tests := []struct {
+		name string
+		msg  Message
+		want int
+	}{
+		{"replayed event", Message{JobID: "job-a", Seq: 3}, 1},
+		{"newer event", Message{JobID: "job-a", Seq: 4}, 2},
+		{"posted to /publish", Message{JobID: "job-a"}, 2},
+	}
+	for _, tt := range tests {
+		if got := hub.Recipients(tt.msg); len(got) != tt.want {
+			t.Errorf("%s: expected %d recipients, got %d", tt.name, tt.want, len(got))
+		}
+	}
+}
@@ -6,7 +6,11 @@ function App() {
   const [results, setResults] = useState(null);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState(null);
+  const [progress, setProgress] = useState(null);
   const socketRef = useRef(null);
+  const jobIdRef = useRef(null);
+  // Resolves once the socket server confirms the subscription to a job
+  const subscribedRef = useRef(null);
 
   // 1. Initialize WebSocket Connection on Mount
   useEffect(() => {
@@ -17,8 +21,27 @@ function App() {
     socket.onopen = () => console.log('Connected to WebSocket Gateway');
     
     socket.onmessage = (event) => {
-      const data = JSON.parse(event.data);
-      console.log('Received data via Socket:', data);
+      const message = JSON.parse(event.data);
+      console.log('Received data via Socket:', message);
+
+      // Only handle messages for the job this client started
+      if (message.job_id !== jobIdRef.current) return;
+      if (message.type === 'subscribed') {
+        if (subscribedRef.current) subscribedRef.current();
+        return;
+      }
+      const data = message.data;
+
+      if (message.type === 'cancelled') {
+        setError('Analysis cancelled.');
+        setLoading(false);
+        return;
+      }
+
+      if (message.type !== 'result' && message.type !== 'failed') {
+        setProgress(describeProgress(message.type, data));
+        return;
+      }
 
       if (data.error) {
         setError(data.error.message);
@@ -42,25 +65,40 @@ function App() {
     setLoading(true);
     setError(null);
     setResults(null);
+    setProgress(null);
 
     try {
-      // Trigger the worker to start analysis
+      // Pick the job ID and subscribe to it before submitting, so no event
+      // published while the request is in flight is missed
+      const socket = socketRef.current;
+      if (!socket || socket.readyState !== WebSocket.OPEN) {
+        throw new Error("Not connected to the socket server.");
+      }
+      const jobId = crypto.randomUUID();
+      jobIdRef.current = jobId;
+      await new Promise((resolve) => {
+        subscribedRef.current = resolve;
+        socket.send(JSON.stringify({ action: 'subscribe', job_id: jobId }));
+        // Go ahead anyway if the confirmation is slow
+        setTimeout(resolve, 2000);
+      });
+      subscribedRef.current = null;
+
+      // Trigger the worker to start analysis. We don't setResults here;
+      // we wait for the WebSocket to push the result.
       const response = await fetch('http://localhost:8080/analyze', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' , 
                    'Idempotency-Key': crypto.randomUUID(),
           },
-        body: JSON.stringify({ url }),
+        body: JSON.stringify({ url, job_id: jobId }),
       });
 
       if (!response.ok) {
         const data = await response.json();
         throw new Error(data.error ? data.error.message : "Backend worker error");
       }
-      
-      // Note: We don't setResults(data) here anymore. 
-      // We wait for the WebSocket to push the message.
-      console.log("Analysis triggered. Waiting for socket broadcast...");
+      console.log("Analysis triggered. Waiting for socket broadcast...", jobId);
 
     } catch (err) {
       setError(err.message || "Could not connect to the backend server.");
@@ -68,6 +106,16 @@ function App() {
     }
   };
 
+  const handleCancel = async () => {
+    if (!jobIdRef.current) return;
+    try {
+      // The cancelled event arrives over the socket and stops the spinner
+      await fetch(`http://localhost:8080/jobs/${jobIdRef.current}`, { method: 'DELETE' });
+    } catch (err) {
+      console.error('Cancel failed:', err);
+    }
+  };
+
   return (
     <div className="container">
       <h1>Web Page Analyzer <span className="badge">Real-time</span></h1>
@@ -89,7 +137,8 @@ function App() {
       {/* Progress feedback for the user */}
       {loading && !results && (
         <div className="loading-spinner">
-          <p>Browser rendering in progress... Waiting for broadcast.</p>
+          <p>{progress || "Browser rendering in progress... Waiting for broadcast."}</p>
+          <button type="button" onClick={handleCancel}>Cancel</button>
         </div>
       )}
 
@@ -120,10 +169,35 @@ function App() {
             <p><strong>External:</strong> {results.links.external_count}</p>
             <p className="danger"><strong>Inaccessible:</strong> {results.links.inaccessible}</p>
           </section>
+
+          {results.screenshot && (
+            <section className="card">
+              <h3>Screenshot</h3>
+              <a href={results.screenshot.url} target="_blank" rel="noreferrer">
+                <img src={results.screenshot.url} alt="Rendered page" style={{ maxWidth: '100%' }} />
+              </a>
+            </section>
+          )}
         </div>
       )}
     </div>
   );
 }
 
+// describeProgress turns a progress event from the worker into a status line
+function describeProgress(type, data) {
+  switch (type) {
+    case 'render_started':
+      return 'Rendering page in headless Chrome...';
+    case 'render_finished':
+      return 'Page rendered. Parsing HTML...';
+    case 'parse_done':
+      return `Found ${data.link_count} links. Checking accessibility...`;
+    case 'link_checked':
+      return `Checked ${data.checked} of ${data.total} links...`;
+    default:
+      return null;
+  }
+}
+
 export default App;
\ No newline at end of file